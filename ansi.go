@@ -0,0 +1,231 @@
+package util
+
+import (
+	"strings"
+)
+
+const (
+	ansiEscape = 0x1b
+	ansiBell   = 0x07
+	osc8Close  = "\033]8;;\033\\" // 结束超链接
+)
+
+// ansiSequenceLen 字符串开头的ANSI转义序列的字节长度，不是转义序列时返回0
+// 支持 CSI（ESC [ ... 终止符）、OSC（ESC ] ... BEL 或 ESC \）和其他两字节的转义序列
+func ansiSequenceLen(s string) int {
+	if len(s) < 2 || s[0] != ansiEscape {
+		return 0
+	}
+	switch s[1] {
+	case '[': // CSI：参数字节 0x30-0x3F，中间字节 0x20-0x2F，终止字节 0x40-0x7E
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+			if s[i] < 0x20 || s[i] > 0x3f {
+				return i // 不完整的序列，到非法字节为止
+			}
+		}
+		return len(s)
+	case ']': // OSC：以 BEL 或 ST(ESC \) 结束
+		for i := 2; i < len(s); i++ {
+			if s[i] == ansiBell {
+				return i + 1
+			}
+			if s[i] == ansiEscape && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	default:
+		return 2
+	}
+}
+
+// StripANSI 移除字符串中的ANSI转义序列（颜色、光标移动、超链接等）
+func StripANSI(s string) string {
+	if strings.IndexByte(s, ansiEscape) < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for s != "" {
+		if n := ansiSequenceLen(s); n > 0 {
+			s = s[n:]
+			continue
+		}
+		i := strings.IndexByte(s[1:], ansiEscape)
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i+1])
+		s = s[i+1:]
+	}
+	return b.String()
+}
+
+// StrTruncate 把字符串截断到不超过 width 列，截断时在末尾加上 tail（例如 "..."）
+// 不会拆开宽字符和转义序列，截断后会关闭未结束的样式和超链接
+func StrTruncate(s string, width int, tail string) string {
+	if StrTerminalLen(s) <= width {
+		return s
+	}
+	tailWidth := StrTerminalLen(tail)
+	if tailWidth > width {
+		tail, tailWidth = "", 0
+	}
+
+	cells, _ := parseANSICells(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	used := 0
+	var last ansiCell
+	for _, cell := range cells {
+		if used+cell.width > width-tailWidth {
+			break
+		}
+		b.WriteString(cell.prefix)
+		b.WriteString(cell.text)
+		used += cell.width
+		last = cell
+	}
+	b.WriteString(tail)
+	b.WriteString(last.closing())
+	return b.String()
+}
+
+// StrPadRight 在右边补空格到 width 列，已超过时原样返回
+func StrPadRight(s string, width int) string {
+	if n := width - StrTerminalLen(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// StrPadLeft 在左边补空格到 width 列，已超过时原样返回
+func StrPadLeft(s string, width int) string {
+	if n := width - StrTerminalLen(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// StrWrap 按 width 列硬换行（不考虑单词边界），原有的换行符保留
+// 每行结尾关闭样式，下一行开头重新打开，单独输出任意一行都不会影响其他内容的样式
+func StrWrap(s string, width int) []string {
+	if width <= 0 {
+		return strings.Split(s, "\n")
+	}
+	cells, suffix := parseANSICells(s)
+	lines := make([]string, 0, 4)
+	var b strings.Builder
+	used := 0
+	var last ansiCell
+	newLine := func(closing string, opening string) {
+		b.WriteString(closing)
+		lines = append(lines, b.String())
+		b.Reset()
+		b.WriteString(opening)
+		used = 0
+		last = ansiCell{}
+	}
+	for _, cell := range cells {
+		if cell.text == "\n" || cell.text == "\r\n" {
+			b.WriteString(cell.prefix)
+			newLine(cell.closing(), cell.opening())
+			continue
+		}
+		if used > 0 && used+cell.width > width {
+			// cell.opening() 已包含 prefix 中的样式
+			newLine(last.closing(), cell.opening())
+		} else {
+			b.WriteString(cell.prefix)
+		}
+		b.WriteString(cell.text)
+		used += cell.width
+		last = cell
+	}
+	b.WriteString(suffix)
+	lines = append(lines, b.String())
+	return lines
+}
+
+// ansiCell 一个可见的字素簇，以及它前面的转义序列和生效的样式
+type ansiCell struct {
+	prefix string // 紧挨着的转义序列
+	text   string // 字素簇
+	width  int
+	style  string // 当前生效的SGR序列（自上次重置后累积）
+	link   string // 当前生效的超链接的OSC 8序列
+}
+
+// sameAs 显示效果是否相同
+func (c ansiCell) sameAs(other ansiCell) bool {
+	return c.text == other.text && c.style == other.style && c.link == other.link
+}
+
+// opening 重新打开这个字符的样式和超链接
+func (c ansiCell) opening() string {
+	return c.style + c.link
+}
+
+// closing 关闭这个字符的样式和超链接
+func (c ansiCell) closing() string {
+	var closing string
+	if c.link != "" {
+		closing += osc8Close
+	}
+	if c.style != "" {
+		closing += reset
+	}
+	return closing
+}
+
+// parseANSICells 把字符串切分为可见的字素簇，最后一个可见字符之后的转义序列作为 suffix 返回
+func parseANSICells(s string) ([]ansiCell, string) {
+	cells := make([]ansiCell, 0, len(s))
+	var style, link string
+	prefixStart := 0
+	for i := 0; i < len(s); {
+		if n := ansiSequenceLen(s[i:]); n > 0 {
+			style, link = applyANSISequence(style, link, s[i:i+n])
+			i += n
+			continue
+		}
+		n, w := nextGrapheme(s[i:])
+		cells = append(cells, ansiCell{
+			prefix: s[prefixStart:i],
+			text:   s[i : i+n],
+			width:  w,
+			style:  style,
+			link:   link,
+		})
+		i += n
+		prefixStart = i
+	}
+	return cells, s[prefixStart:]
+}
+
+// applyANSISequence 根据转义序列更新生效的样式和超链接
+func applyANSISequence(style, link, seq string) (string, string) {
+	switch {
+	case strings.HasPrefix(seq, "\033]8;"): // OSC 8 ; 参数 ; URI，URI为空表示结束
+		body := strings.TrimSuffix(strings.TrimSuffix(seq[4:], "\033\\"), "\a")
+		if i := strings.IndexByte(body, ';'); i >= 0 && body[i+1:] != "" {
+			return style, seq
+		}
+		return style, ""
+	case strings.HasPrefix(seq, "\033[") && strings.HasSuffix(seq, "m"): // SGR
+		params := seq[2 : len(seq)-1]
+		switch {
+		case params == "" || params == "0":
+			return "", link
+		case strings.HasPrefix(params, "0;"):
+			return seq, link
+		default:
+			return style + seq, link
+		}
+	}
+	return style, link
+}
@@ -0,0 +1,189 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchErrorMode 批处理出错时的处理方式
+type BatchErrorMode int
+
+const (
+	BatchFailFast   BatchErrorMode = iota // 一批失败（重试后）就取消其他批，不再开始新的批
+	BatchCollectAll                       // 继续处理所有批，收集每批的错误
+)
+
+// BatchOptions 批处理的选项
+type BatchOptions struct {
+	BatchSize   int                  // 每批最多的元素个数，<=0 时整个输入作为一批
+	Concurrency int                  // 同时处理的批数，<=0 时为1
+	Retries     int                  // 每批失败后的重试次数
+	RetryDelay  time.Duration        // 第一次重试前的等待时间，之后每次加倍
+	ShouldRetry func(err error) bool // 判断错误是否需要重试，为nil时都重试
+	Mode        BatchErrorMode
+}
+
+// BatchError 一批的错误
+type BatchError struct {
+	Index    int // 批的下标
+	Start    int // 这一批第一个元素在输入中的下标
+	Size     int // 这一批的元素个数
+	Attempts int // 执行的次数，0表示因为 ctx 取消没有执行
+	Err      error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch %d (items %d-%d, %d attempts): %v", e.Index, e.Start, e.Start+e.Size-1, e.Attempts, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchErrors 多批的错误，按批的下标排序，可以用 errors.Is/As 检查其中的错误
+type BatchErrors []*BatchError
+
+func (e BatchErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d batches failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+func (e BatchErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// BatchExecute 用 SplitSliceByMaxLength 把 src 分批，以有限的并发处理，结果按批的顺序返回
+// 参数:
+//
+//	ctx - 取消后不再开始新的批，正在处理的批通过 process 的 ctx 得知
+//	src - 需要处理的元素，例如调用接口的ID
+//	opts - 批大小、并发数、重试和出错时的处理方式
+//	process - 处理一批，返回这一批的结果；批是 src 的子切片，不要修改
+//
+// 返回值:
+//
+//	[]R - 长度等于批数，results[i] 是第i批的结果，失败或没有执行的批为零值
+//	error - 没有错误时为nil，否则为 BatchErrors
+//	        ctx 取消时两种模式都包含没有执行的批（Attempts 为0，Err 为 ctx.Err()）
+//	        BatchCollectAll 包含所有失败的批
+//	        BatchFailFast 包含导致停止的批和被取消的正在处理的批，因为停止而没有开始的批不包含在内
+func BatchExecute[T any, R any](ctx context.Context, src []T, opts BatchOptions, process func(ctx context.Context, batch []T) (R, error)) ([]R, error) {
+	if len(src) == 0 {
+		return []R{}, nil
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = len(src)
+	}
+	batches := SplitSliceByMaxLength(src, size)
+	results := make([]R, len(batches))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var errs BatchErrors
+	stopped := false // FailFast 因为失败主动取消了
+	fail := func(err *BatchError) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+		if opts.Mode == BatchFailFast && err.Attempts > 0 {
+			// 调用方已经取消时，失败是取消造成的，不算主动停止
+			stopped = stopped || ctx.Err() == nil
+			cancel()
+		}
+	}
+	// skip 没有开始的批，调用方取消时记录为没有执行的批，FailFast 主动停止的不记录
+	skip := func(i int) {
+		mu.Lock()
+		callerCancelled := ctx.Err() != nil && !stopped
+		mu.Unlock()
+		if callerCancelled {
+			fail(&BatchError{Index: i, Start: i * size, Size: len(batches[i]), Err: ctx.Err()})
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(opts.Concurrency, 1), len(batches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if runCtx.Err() != nil { // 已取消，不再开始这一批
+					skip(i)
+					continue
+				}
+				result, attempts, err := runBatch(runCtx, batches[i], opts, process)
+				if err != nil {
+					fail(&BatchError{Index: i, Start: i * size, Size: len(batches[i]), Attempts: attempts, Err: err})
+					continue
+				}
+				results[i] = result
+			}
+		}()
+	}
+
+	started := 0
+feed:
+	for ; started < len(batches); started++ {
+		// select 在两个分支都就绪时随机选择，先检查避免取消后仍然发出新的批
+		if runCtx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- started:
+		case <-runCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := started; i < len(batches); i++ {
+		skip(i)
+	}
+	if len(errs) == 0 {
+		return results, nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Index < errs[j].Index })
+	return results, errs
+}
+
+// runBatch 处理一批，失败时按选项重试，返回执行的次数
+func runBatch[T any, R any](ctx context.Context, batch []T, opts BatchOptions, process func(ctx context.Context, batch []T) (R, error)) (R, int, error) {
+	delay := opts.RetryDelay
+	attempts := 0
+	for {
+		result, err := process(ctx, batch)
+		attempts++
+		if err == nil {
+			return result, attempts, nil
+		}
+		if attempts > opts.Retries || (opts.ShouldRetry != nil && !opts.ShouldRetry(err)) || ctx.Err() != nil {
+			return result, attempts, err
+		}
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, attempts, err
+			case <-timer.C:
+			}
+			delay *= 2
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatchExecuteCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, mode := range []BatchErrorMode{BatchFailFast, BatchCollectAll} {
+		called := 0
+		results, err := BatchExecute(ctx, []int{1, 2, 3, 4}, BatchOptions{BatchSize: 2, Mode: mode}, func(ctx context.Context, batch []int) (int, error) {
+			called++
+			return len(batch), nil
+		})
+		if called != 0 {
+			t.Errorf("mode %d: process called %d times, want 0", mode, called)
+		}
+		if len(results) != 2 {
+			t.Errorf("mode %d: got %d results, want 2", mode, len(results))
+		}
+		var errs BatchErrors
+		if !errors.As(err, &errs) || len(errs) != 2 {
+			t.Fatalf("mode %d: got error %v, want 2 batch errors", mode, err)
+		}
+		for i, e := range errs {
+			if e.Index != i || e.Attempts != 0 || !errors.Is(e, context.Canceled) {
+				t.Errorf("mode %d: errs[%d] = %v", mode, i, e)
+			}
+		}
+	}
+}
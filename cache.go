@@ -0,0 +1,254 @@
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cacheFileExt = ".cache" // Cache 在目录中保存的文件后缀
+
+// Cache 基于目录的带过期时间的类型化缓存
+// 每个key保存为目录中的一个文件，通过 SaveToCache/LoadFromCache 读写
+type Cache[T any] struct {
+	dir      string
+	maxBytes int64 // 目录中缓存文件的总大小上限，<=0 表示不限制
+
+	fileMu sync.RWMutex // 保护本进程内对缓存文件的读写
+	mu     sync.Mutex   // 保护 calls
+	calls  map[string]*cacheCall[T]
+}
+
+// 正在执行的 loader，相同key的并发调用共享同一个结果
+type cacheCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// 缓存文件的内容
+type cacheEntry[T any] struct {
+	Key      string    // 原始key，用于校验文件名的哈希冲突
+	ExpireAt time.Time // 过期时间，零值表示永不过期
+	Value    T
+}
+
+// NewCache 创建缓存，dir 不存在时自动创建
+// maxBytes 为缓存文件总大小的上限，超过后按写入时间从旧到新淘汰，<=0 表示不限制
+func NewCache[T any](dir string, maxBytes int64) (*Cache[T], error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache[T]{
+		dir:      dir,
+		maxBytes: maxBytes,
+		calls:    make(map[string]*cacheCall[T]),
+	}, nil
+}
+
+// Get 获取缓存
+// 返回值:
+//
+//	T - 缓存的值
+//	bool - 缓存存在且未过期时为true
+//	error - 读取或解码缓存文件失败
+func (c *Cache[T]) Get(key string) (T, bool, error) {
+	var zero T
+
+	c.fileMu.RLock()
+	entry, err := LoadFromCache[cacheEntry[T]](c.path(key))
+	c.fileMu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return zero, false, nil
+		}
+		return zero, false, err
+	}
+	if entry.Key != key {
+		return zero, false, nil
+	}
+	if !entry.ExpireAt.IsZero() && time.Now().After(entry.ExpireAt) {
+		return zero, false, nil
+	}
+	return entry.Value, true, nil
+}
+
+// Set 写入缓存，ttl<=0 表示永不过期
+func (c *Cache[T]) Set(key string, value T, ttl time.Duration) error {
+	entry := cacheEntry[T]{Key: key, Value: value}
+	if ttl > 0 {
+		entry.ExpireAt = time.Now().Add(ttl)
+	}
+
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	if err := SaveToCache(entry, c.path(key)); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// GetOrLoad 缓存命中直接返回，否则调用 loader 获取并写入缓存
+// 相同key的并发调用只会执行一次 loader，其余调用等待并共享结果
+// 缓存文件损坏时视为未命中，用 loader 的结果覆盖
+func (c *Cache[T]) GetOrLoad(key string, ttl time.Duration, loader func() (T, error)) (T, error) {
+	if value, ok, err := c.Get(key); err == nil && ok {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &cacheCall[T]{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		// loader panic 时也要唤醒等待者，等待者得到 PanicError，当前调用继续panic
+		r := recover()
+		if r != nil {
+			call.err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	call.value, call.err = loader()
+	if call.err != nil {
+		return call.value, call.err
+	}
+	if err := c.Set(key, call.value, ttl); err != nil {
+		call.err = fmt.Errorf("save cache %s failed: %w", key, err)
+	}
+	return call.value, call.err
+}
+
+// Invalidate 删除缓存，缓存不存在时不报错
+func (c *Cache[T]) Invalidate(key string) error {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Clear 删除目录中的所有缓存文件
+func (c *Cache[T]) Clear() error {
+	c.fileMu.Lock()
+	defer c.fileMu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Size 缓存文件的总大小
+func (c *Cache[T]) Size() (int64, error) {
+	c.fileMu.RLock()
+	defer c.fileMu.RUnlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	return total, nil
+}
+
+// key可能包含路径分隔符等非法字符，所以用哈希作为文件名
+func (c *Cache[T]) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+cacheFileExt)
+}
+
+type cacheFileInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *Cache[T]) files() ([]cacheFileInfo, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]cacheFileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), cacheFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) { // 被其他进程删除了
+				continue
+			}
+			return nil, err
+		}
+		files = append(files, cacheFileInfo{
+			path:    filepath.Join(c.dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// 总大小超过上限时，按写入时间从旧到新删除。调用方需持有 fileMu 写锁
+func (c *Cache[T]) evict() error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	if total <= c.maxBytes {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= f.size
+	}
+	return nil
+}
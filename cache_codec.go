@@ -0,0 +1,273 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// 缓存文件头：magic(4) + 编码名长度(1) + 编码名 + 数据版本(4, 大端) + 数据的crc32(4, 大端)
+// 没有文件头的旧文件视为 gob 编码、版本0，没有校验和
+const (
+	cacheHeaderMagic  = "GUC1"
+	cacheBackupSuffix = ".bak" // SaveToCacheWithBackup 保留的上一个版本的后缀
+)
+
+var (
+	// ErrCacheCodecMismatch 缓存文件的编码方式与读取时指定的不一致
+	ErrCacheCodecMismatch = errors.New("cache codec mismatch")
+	// ErrCacheVersionMismatch 缓存文件的数据版本与读取时指定的不一致
+	ErrCacheVersionMismatch = errors.New("cache version mismatch")
+	// ErrCacheCorrupted 缓存文件损坏（校验和不一致、文件头或数据不完整）
+	ErrCacheCorrupted = errors.New("cache corrupted")
+)
+
+// CacheCodec 缓存的序列化方式
+type CacheCodec interface {
+	// Name 编码名，写入缓存文件头，长度不能超过255
+	Name() string
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	// GobCodec encoding/gob，只适用于Go程序之间，结构体字段变化时容易解码失败
+	GobCodec CacheCodec = gobCodec{}
+	// JSONCodec encoding/json，可读性好，可以跨语言
+	JSONCodec CacheCodec = jsonCodec{}
+)
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Encode(w io.Writer, v any) error { return gob.NewEncoder(w).Encode(v) }
+
+func (gobCodec) Decode(r io.Reader, v any) error { return gob.NewDecoder(r).Decode(v) }
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+
+func (jsonCodec) Decode(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) }
+
+// GzipCodec 在 inner 的基础上进行gzip压缩，编码名为 "gzip+" + inner.Name()
+func GzipCodec(inner CacheCodec) CacheCodec {
+	return gzipCodec{inner: inner}
+}
+
+type gzipCodec struct {
+	inner CacheCodec
+}
+
+func (c gzipCodec) Name() string { return "gzip+" + c.inner.Name() }
+
+func (c gzipCodec) Encode(w io.Writer, v any) error {
+	zw := gzip.NewWriter(w)
+	if err := c.inner.Encode(zw, v); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+func (c gzipCodec) Decode(r io.Reader, v any) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return c.inner.Decode(zr, v)
+}
+
+// SaveToCacheWithCodec 用指定的编码方式写缓存，文件头记录编码名和数据版本
+// version 由调用方维护，结构体不兼容地变化时递增，读取时版本不一致会返回 ErrCacheVersionMismatch
+// 先写临时文件再Rename，写到一半崩溃不会破坏原有的缓存文件
+func SaveToCacheWithCodec[T any](data T, cacheFile string, codec CacheCodec, version uint32) error {
+	return saveCacheFile(cacheFile, codec, version, data, false)
+}
+
+// LoadFromCacheWithCodec 用指定的编码方式读缓存
+// 编码方式不一致返回 ErrCacheCodecMismatch，版本不一致返回 ErrCacheVersionMismatch，
+// 校验和不一致返回 ErrCacheCorrupted，用 errors.Is 判断
+func LoadFromCacheWithCodec[T any](cacheFile string, codec CacheCodec, version uint32) (T, error) {
+	var data T
+
+	header, payload, err := readCacheFile(cacheFile)
+	if err != nil {
+		return data, err
+	}
+	if header.name != codec.Name() {
+		return data, fmt.Errorf("%w: %s is %s, want %s", ErrCacheCodecMismatch, cacheFile, header.name, codec.Name())
+	}
+	if header.version != version {
+		return data, fmt.Errorf("%w: %s is version %d, want %d", ErrCacheVersionMismatch, cacheFile, header.version, version)
+	}
+	if err := codec.Decode(bytes.NewReader(payload), &data); err != nil {
+		return data, fmt.Errorf("decode %s failed: %w", cacheFile, err)
+	}
+	return data, nil
+}
+
+// SaveToCacheWithBackup 同 SaveToCache，写入前把当前完好的缓存文件保留为 cacheFile + ".bak"
+// 配合 LoadFromCacheWithFallback 使用，其他编码方式用 SaveToCacheWithCodecBackup
+func SaveToCacheWithBackup[T any](data T, cacheFile string) error {
+	return SaveToCacheWithCodecBackup(data, cacheFile, GobCodec, 0)
+}
+
+// LoadFromCacheWithFallback 同 LoadFromCache，读取失败时改为读取 SaveToCacheWithBackup 保留的上一个版本
+// 两者都失败时返回读取 cacheFile 的错误
+func LoadFromCacheWithFallback[T any](cacheFile string) (T, error) {
+	return LoadFromCacheWithCodecFallback[T](cacheFile, GobCodec, 0)
+}
+
+// SaveToCacheWithCodecBackup 同 SaveToCacheWithCodec，写入前把当前完好的缓存文件保留为 cacheFile + ".bak"
+// 配合 LoadFromCacheWithCodecFallback 使用
+func SaveToCacheWithCodecBackup[T any](data T, cacheFile string, codec CacheCodec, version uint32) error {
+	return saveCacheFile(cacheFile, codec, version, data, true)
+}
+
+// LoadFromCacheWithCodecFallback 同 LoadFromCacheWithCodec，读取失败时改为读取 SaveToCacheWithCodecBackup 保留的上一个版本
+// 上一个版本的编码方式或数据版本不一致时同样视为失败；两者都失败时返回读取 cacheFile 的错误
+func LoadFromCacheWithCodecFallback[T any](cacheFile string, codec CacheCodec, version uint32) (T, error) {
+	data, err := LoadFromCacheWithCodec[T](cacheFile, codec, version)
+	if err == nil {
+		return data, nil
+	}
+	if backup, backupErr := LoadFromCacheWithCodec[T](cacheFile+cacheBackupSuffix, codec, version); backupErr == nil {
+		return backup, nil
+	}
+	return data, err
+}
+
+type cacheFileHeader struct {
+	name        string
+	version     uint32
+	hasChecksum bool // 没有文件头的旧文件没有校验和
+	checksum    uint32
+}
+
+func saveCacheFile(cacheFile string, codec CacheCodec, version uint32, data any, keepBackup bool) error {
+	var payload bytes.Buffer
+	if err := codec.Encode(&payload, data); err != nil {
+		return err
+	}
+	header, err := cacheHeader(codec.Name(), version, crc32.ChecksumIEEE(payload.Bytes()))
+	if err != nil {
+		return err
+	}
+
+	if keepBackup {
+		if err := backupCacheFile(cacheFile); err != nil {
+			return err
+		}
+	}
+
+	content := make([]byte, 0, len(header)+payload.Len())
+	content = append(content, header...)
+	content = append(content, payload.Bytes()...)
+	return SyncAtomicWriteSmallFile(cacheFile, content, 0666)
+}
+
+// backupCacheFile 当前缓存文件校验通过时，保留为 .bak。用硬链接保证读者任何时刻都能读到 cacheFile
+func backupCacheFile(cacheFile string) error {
+	header, _, err := readCacheFile(cacheFile)
+	if err != nil {
+		if os.IsNotExist(err) || errors.Is(err, ErrCacheCorrupted) { // 没有可保留的版本
+			return nil
+		}
+		return err
+	}
+	if !header.hasChecksum { // 旧格式的文件无法确认是否完好
+		return nil
+	}
+
+	backupFile := cacheFile + cacheBackupSuffix
+	if err := os.Remove(backupFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(cacheFile, backupFile); err == nil {
+		return nil
+	}
+	// 文件系统不支持硬链接时复制一份
+	content, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return err
+	}
+	return SyncAtomicWriteSmallFile(backupFile, content, 0666)
+}
+
+// readCacheFile 读取文件头和数据，有校验和时校验数据
+func readCacheFile(cacheFile string) (cacheFileHeader, []byte, error) {
+	f, err := os.Open(cacheFile)
+	if err != nil {
+		return cacheFileHeader{}, nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header, err := readCacheHeader(r)
+	if err != nil {
+		return header, nil, fmt.Errorf("%w: read header of %s failed: %w", ErrCacheCorrupted, cacheFile, err)
+	}
+	payload, err := io.ReadAll(r)
+	if err != nil {
+		return header, nil, err
+	}
+	if header.hasChecksum && crc32.ChecksumIEEE(payload) != header.checksum {
+		return header, nil, fmt.Errorf("%w: checksum of %s mismatch", ErrCacheCorrupted, cacheFile)
+	}
+	return header, payload, nil
+}
+
+func cacheHeader(name string, version uint32, checksum uint32) ([]byte, error) {
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("invalid cache codec name %q", name)
+	}
+	header := make([]byte, 0, len(cacheHeaderMagic)+1+len(name)+8)
+	header = append(header, cacheHeaderMagic...)
+	header = append(header, byte(len(name)))
+	header = append(header, name...)
+	header = binary.BigEndian.AppendUint32(header, version)
+	header = binary.BigEndian.AppendUint32(header, checksum)
+	return header, nil
+}
+
+// readCacheHeader 读取文件头，没有文件头的旧文件返回 gob 和版本0，且不消耗数据
+func readCacheHeader(r *bufio.Reader) (cacheFileHeader, error) {
+	magic, err := r.Peek(len(cacheHeaderMagic))
+	if err != nil { // 不到4个字节，旧的gob文件也不可能是完整的
+		return cacheFileHeader{}, err
+	}
+	if !bytes.Equal(magic, []byte(cacheHeaderMagic)) {
+		return cacheFileHeader{name: GobCodec.Name()}, nil
+	}
+	if _, err := r.Discard(len(cacheHeaderMagic)); err != nil {
+		return cacheFileHeader{}, err
+	}
+
+	nameLen, err := r.ReadByte()
+	if err != nil {
+		return cacheFileHeader{}, err
+	}
+	buf := make([]byte, int(nameLen)+8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return cacheFileHeader{}, err
+	}
+	return cacheFileHeader{
+		name:        string(buf[:nameLen]),
+		version:     binary.BigEndian.Uint32(buf[nameLen:]),
+		hasChecksum: true,
+		checksum:    binary.BigEndian.Uint32(buf[int(nameLen)+4:]),
+	}, nil
+}
@@ -2,7 +2,9 @@ package util
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 const (
@@ -17,6 +19,7 @@ const (
 	saveCursor    = "\033[s"    // 保存光标位置
 	restoreCursor = "\033[u"    // 恢复光标位置
 	clearLine     = "\033[2K"   // 清除整行
+	clearToEnd    = "\033[J"    // 清除光标到屏幕末尾的内容
 	clearScreen   = "\033[2J"   // 清屏
 	moveToHome    = "\033[H"    // 移动光标到左上角
 	moveToCol     = "\033[%dG"  // 移动到指定列
@@ -25,168 +28,351 @@ const (
 )
 
 type printDiff struct {
+	out       io.Writer
+	plain     bool // 输出不是终端时不使用转义序列，只逐行输出变化了的行
 	lines     []string
 	prevLines []string
+
+	addedStyle   Style // 新插入内容的高亮样式
+	changedStyle Style // 替换了原有内容的高亮样式
+	fadeAfter    int   // 高亮保持的刷新次数，0表示保持到该行再次变化
+	fades        []int // 每行高亮剩余的刷新次数
+
+	term     *os.File     // 用于获取终端的大小，为nil时不限制
+	viewport TermSize     // 手动设置的可视区域大小，为0的维度使用终端的大小
+	lastSize TermSize     // 上次输出时的可视区域大小，用于发现终端大小的变化
+	overflow LineOverflow // 超出宽度的行的处理方式
+	scroll   int          // 可视区域第一行在全部（折行后的）行中的下标
 }
 
 func NewPrintCharPositionDiff() *printDiff {
+	return newPrintDiff(os.Stdout)
+}
+
+func newPrintDiff(out io.Writer) *printDiff {
+	term, _ := out.(*os.File)
 	return &printDiff{
+		term:      term,
+		out:       out,
+		plain:     !isTerminalWriter(out),
 		lines:     make([]string, 0),
 		prevLines: make([]string, 0),
+
+		addedStyle:   NewStyle().Reverse(),
+		changedStyle: NewStyle().Reverse(),
 	}
 }
 
+// SetHighlightStyles 设置变化内容的高亮样式，默认都是反色
+// 参数:
+//
+//	added - 新插入的内容（没有替换原有内容）
+//	changed - 替换了原有内容的部分
+//
+// 传入 NewStyle() 表示不高亮
+func (lu *printDiff) SetHighlightStyles(added Style, changed Style) {
+	lu.addedStyle = added
+	lu.changedStyle = changed
+}
+
+// SetHighlightFade 高亮在之后的 refreshes 次 SetLines 后消失，<=0 表示保持到该行再次变化
+func (lu *printDiff) SetHighlightFade(refreshes int) {
+	lu.fadeAfter = max(refreshes, 0)
+}
+
 // Start 初始化输出环境（隐藏光标、清屏）
 func (lu *printDiff) Start() {
+	if lu.plain {
+		return
+	}
 	// 隐藏光标
-	fmt.Print(hideCursor)
+	fmt.Fprint(lu.out, hideCursor)
 	// 清屏
-	fmt.Print(clearScreen)
-	fmt.Print(moveToHome)
+	fmt.Fprint(lu.out, clearScreen)
+	fmt.Fprint(lu.out, moveToHome)
 }
 
 // Close 清理输出环境（显示光标、换行）
 func (lu *printDiff) Close() {
+	if lu.plain {
+		return
+	}
 	// 显示光标
-	fmt.Print(showCursor)
+	fmt.Fprint(lu.out, showCursor)
 	// 换行
-	fmt.Print("\n")
+	fmt.Fprint(lu.out, "\n")
 }
 
 // 设置所有行
+// 超出终端宽度的行按 SetLineOverflow 裁剪或折行，超出终端高度时只显示可视区域内的行，见 ScrollTo
 func (lu *printDiff) SetLines(lines []string) {
+	lu.lines = append(lu.lines[:0], lines...)
+	if lu.plain {
+		lu.setPlainLines(lines)
+		return
+	}
+
+	// 终端大小变化后，原来输出的内容可能被终端重新折行，清除后完整重绘
+	size := lu.viewportSize()
+	if size != lu.lastSize && len(lu.prevLines) > 0 {
+		lu.clearRegion(size.Cols)
+	}
+	lu.lastSize = size
+	lu.drawRows(lu.layout(lines, size))
+}
+
+// 输出屏幕上的各行，只刷新变化的部分
+func (lu *printDiff) drawRows(lines []string) {
 	// 保存旧的行数（通过计算 prevLines 的长度得到）
 	oldLineCount := len(lu.prevLines)
 
-	// 移动到第一行（如果之前有内容，向上移动；如果是第一次调用，也移动到第一行）
-	n := len(lu.prevLines)
-	if n == 0 {
-		n = len(lines)
+	// 移动到第一行（光标停在上次输出的最后一行，第一次调用时就在第一行）
+	if oldLineCount > 1 {
+		fmt.Fprintf(lu.out, moveUp, oldLineCount-1)
 	}
-	if n > 0 {
-		fmt.Printf(moveUp, n)
+
+	// 高亮的剩余次数与行对应
+	if len(lu.fades) > len(lines) {
+		lu.fades = lu.fades[:len(lines)]
+	}
+	for len(lu.fades) < len(lines) {
+		lu.fades = append(lu.fades, 0)
 	}
 
 	// 更新每一行
 	for i, line := range lines {
 		lu.updateLine(i, line)
 		if i < len(lines)-1 {
-			fmt.Print("\n")
+			fmt.Fprint(lu.out, "\n")
 		}
 	}
 
 	// 如果行数减少，清除多余的行
-	if len(lines) < oldLineCount {
+	if len(lines) == 0 && oldLineCount > 0 {
+		// 光标停在原来的第一行上，从这一行开始清除
+		fmt.Fprint(lu.out, "\r"+clearToEnd)
+	} else if len(lines) < oldLineCount {
 		for i := len(lines); i < oldLineCount; i++ {
-			fmt.Print("\n")
-			fmt.Print(clearLine)
+			fmt.Fprint(lu.out, "\n")
+			fmt.Fprint(lu.out, clearLine)
 		}
 		// 移回最后一行
-		fmt.Printf(moveUp, oldLineCount-len(lines))
+		fmt.Fprintf(lu.out, moveUp, oldLineCount-len(lines))
 	}
 
 	// 刷新输出
-	_ = os.Stdout.Sync()
+	if f, ok := lu.out.(*os.File); ok {
+		_ = f.Sync()
+	}
 
 	// 保存当前状态
-	lu.lines = make([]string, len(lines))
-	copy(lu.lines, lines)
-	lu.prevLines = make([]string, len(lines))
-	copy(lu.prevLines, lines)
+	lu.prevLines = append(lu.prevLines[:0], lines...)
+}
+
+// 不是终端时，只输出变化了的行（去掉颜色等转义序列），适合重定向到日志文件
+func (lu *printDiff) setPlainLines(lines []string) {
+	for i, line := range lines {
+		if i < len(lu.prevLines) && lu.prevLines[i] == line {
+			continue
+		}
+		fmt.Fprintln(lu.out, StripANSI(line))
+	}
+	lu.prevLines = append(lu.prevLines[:0], lines...)
 }
 
 // 更新单行，只刷新变化的部分
 func (lu *printDiff) updateLine(lineNum int, newContent string) {
 	if lineNum >= len(lu.prevLines) {
 		// 新行，直接输出（正常显示，不加反转效果，因为这是初始内容）
-		fmt.Print(newContent)
+		fmt.Fprint(lu.out, newContent)
 		return
 	}
 
 	oldContent := lu.prevLines[lineNum]
 	if oldContent == newContent {
-		// 内容没有变化，不更新
+		// 内容没有变化，只处理高亮的消失
+		lu.fadeLine(lineNum, newContent)
 		return
 	}
 
-	// 计算段列表（同步比较rune，连续相同/不同作为segment）
+	// 计算段列表（按可见字符及其样式做最长公共子序列，未变化/新增/替换的连续部分作为segment）
 	segments := lu.calculateSegments(oldContent, newContent)
 
 	// 移动到该行的开始位置
-	fmt.Printf(moveToCol, 1)
-	fmt.Print(reset)
+	fmt.Fprintf(lu.out, moveToCol, 1)
+	fmt.Fprint(lu.out, reset)
 
 	// 遍历所有segment，重新输出整行
 	for _, seg := range segments {
-		if seg.isFixed {
+		switch seg.kind {
+		case segmentAdded:
+			fmt.Fprint(lu.out, renderCells(seg.cells, lu.addedStyle.Sequence()))
+		case segmentChanged:
+			fmt.Fprint(lu.out, renderCells(seg.cells, lu.changedStyle.Sequence()))
+		default:
 			// 固定段：正常显示
-			fmt.Print(reset + seg.content)
-		} else {
-			// 变化段：带反转效果
-			fmt.Print(reverse + seg.content + reset)
+			fmt.Fprint(lu.out, renderCells(seg.cells, ""))
 		}
 	}
 
 	// 如果旧内容比新内容长，清除末尾多余的字符
-	oldWidth := lu.displayWidth(oldContent)
-	newWidth := lu.displayWidth(newContent)
+	oldWidth := StrTerminalLen(oldContent)
+	newWidth := StrTerminalLen(newContent)
 	if oldWidth > newWidth {
 		for i := newWidth; i < oldWidth; i++ {
-			fmt.Print(" ")
+			fmt.Fprint(lu.out, " ")
 		}
 	}
+	lu.fades[lineNum] = lu.fadeAfter
 }
 
-// 计算字符的显示宽度（中文字符占2个位置，英文字符占1个位置）
-func (lu *printDiff) displayWidth(s string) int {
-	width := 0
-	for _, r := range s {
-		if r >= 0x1100 && (r <= 0x115F || r >= 0x2E80 && r <= 0x9FFF ||
-			r >= 0xAC00 && r <= 0xD7AF || r >= 0xF900 && r <= 0xFAFF ||
-			r >= 0xFE30 && r <= 0xFE4F || r >= 0x1F200 && r <= 0x1F2FF) {
-			width += 2 // 中文字符或宽字符
-		} else {
-			width += 1 // 普通字符
-		}
+// 没有变化的行，高亮次数用完后去掉高亮重新输出
+func (lu *printDiff) fadeLine(lineNum int, content string) {
+	if lu.fades[lineNum] == 0 {
+		return
 	}
-	return width
+	lu.fades[lineNum]--
+	if lu.fades[lineNum] > 0 {
+		return
+	}
+	cells, _ := parseANSICells(content)
+	fmt.Fprintf(lu.out, moveToCol, 1)
+	fmt.Fprint(lu.out, renderCells(cells, ""))
 }
 
-// 段类型：固定段或变化段
+// 段类型
+type segmentKind int
+
+const (
+	segmentFixed   segmentKind = iota // 固定段，与旧内容相同
+	segmentAdded                      // 新增段，插入在旧内容之间
+	segmentChanged                    // 变化段，替换了旧内容
+)
+
 type segment struct {
-	isFixed bool   // true表示固定段，false表示变化段
-	content string // 段内容
+	kind  segmentKind
+	cells []ansiCell // 段内容
 }
 
-// 计算两个字符串的差异（同步比较rune，连续相同/不同作为segment）
+// 超过这个规模（旧字符数*新字符数）不做最长公共子序列，退化为逐个位置比较
+const maxDiffCells = 1 << 20
+
+// 计算两个字符串的差异
+// 按可见字符及其样式求最长公共子序列，使插入或删除一个字符只影响这一个字符，而不是后面的整行
+// 转义序列不单独比较，只作为字符的样式参与比较，避免把颜色代码当成变化的内容
 func (lu *printDiff) calculateSegments(old, new string) []segment {
-	oldRunes := []rune(old)
-	newRunes := []rune(new)
-	oldLen := len(oldRunes)
-	newLen := len(newRunes)
+	oldCells, _ := parseANSICells(old)
+	newCells, _ := parseANSICells(new)
+
+	// 去掉相同的开头和结尾，只对中间部分求最长公共子序列
+	prefix := 0
+	for prefix < len(oldCells) && prefix < len(newCells) && oldCells[prefix].sameAs(newCells[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldCells)-prefix && suffix < len(newCells)-prefix &&
+		oldCells[len(oldCells)-1-suffix].sameAs(newCells[len(newCells)-1-suffix]) {
+		suffix++
+	}
+
+	kinds := make([]segmentKind, len(newCells)) // 每个新字符所属的段类型
+	oldMid := oldCells[prefix : len(oldCells)-suffix]
+	newMid := newCells[prefix : len(newCells)-suffix]
+	if len(oldMid)*len(newMid) > maxDiffCells {
+		for i := range newMid {
+			if i < len(oldMid) && newMid[i].sameAs(oldMid[i]) {
+				continue
+			}
+			kinds[prefix+i] = segmentChanged
+		}
+	} else {
+		diffCellKinds(oldMid, newMid, kinds[prefix:prefix+len(newMid)])
+	}
+
+	// 连续相同类型的字符合并为一段
 	var segments []segment
-	idx := 0
-
-	// 以 newRunes 为准，仅遍历 newRunes
-	for idx < newLen {
-		// 找到连续相同的部分（固定段）
-		start := idx
-		for idx < newLen && idx < oldLen && newRunes[idx] == oldRunes[idx] {
-			idx++
+	for start := 0; start < len(newCells); {
+		end := start + 1
+		for end < len(newCells) && kinds[end] == kinds[start] {
+			end++
 		}
-		if idx > start {
-			segments = append(segments, segment{isFixed: true, content: string(newRunes[start:idx])})
+		segments = append(segments, segment{kind: kinds[start], cells: newCells[start:end]})
+		start = end
+	}
+	return segments
+}
+
+// diffCellKinds 用最长公共子序列标记每个新字符是未变化、新增还是替换
+// 两个公共字符之间，旧内容有被删除的字符时插入的字符为替换，否则为新增
+func diffCellKinds(oldCells, newCells []ansiCell, kinds []segmentKind) {
+	m, n := len(oldCells), len(newCells)
+	// lcs[i][j] 为 oldCells[i:] 和 newCells[j:] 的最长公共子序列长度
+	lcs := make([][]int32, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, n+1)
+	}
+	for i := m - 1; i >= 0; i-- {
+		for j := n - 1; j >= 0; j-- {
+			if oldCells[i].sameAs(newCells[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
 		}
+	}
 
-		// 找到连续不同的部分（变化段）
-		start = idx
-		for idx < newLen && (idx >= oldLen || newRunes[idx] != oldRunes[idx]) {
-			idx++
+	hunkStart := 0       // 当前差异块在 newCells 中的起点
+	hunkDeleted := false // 当前差异块是否删除了旧字符
+	closeHunk := func(end int) {
+		kind := segmentAdded
+		if hunkDeleted {
+			kind = segmentChanged
 		}
-		if idx > start {
-			segments = append(segments, segment{isFixed: false, content: string(newRunes[start:idx])})
+		for k := hunkStart; k < end; k++ {
+			kinds[k] = kind
 		}
 	}
 
-	return segments
+	i, j := 0, 0
+	for i < m || j < n {
+		switch {
+		case i < m && j < n && oldCells[i].sameAs(newCells[j]):
+			closeHunk(j)
+			kinds[j] = segmentFixed
+			i++
+			j++
+			hunkStart, hunkDeleted = j, false
+		case j == n || (i < m && lcs[i+1][j] >= lcs[i][j+1]):
+			hunkDeleted = true
+			i++
+		default:
+			j++
+		}
+	}
+	closeHunk(n)
+}
+
+// renderCells 输出一段字符，样式变化时先重置再打开该字符的样式，highlight 叠加在字符自己的样式之后
+// 结尾重置样式，不影响后面的内容
+func renderCells(cells []ansiCell, highlight string) string {
+	var b strings.Builder
+	var prev ansiCell
+	for i, cell := range cells {
+		if i == 0 || cell.style != prev.style {
+			b.WriteString(reset + cell.style + highlight)
+		}
+		if cell.link != prev.link {
+			if prev.link != "" {
+				b.WriteString(osc8Close)
+			}
+			b.WriteString(cell.link)
+		}
+		b.WriteString(cell.text)
+		prev = cell
+	}
+	if prev.link != "" {
+		b.WriteString(osc8Close)
+	}
+	b.WriteString(reset)
+	return b.String()
 }
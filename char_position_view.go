@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+)
+
+// LineOverflow 超出可视区域宽度的行的处理方式
+type LineOverflow int
+
+const (
+	LineClip LineOverflow = iota // 裁剪，结尾显示省略号
+	LineWrap                     // 折行为多行
+)
+
+// 裁剪时结尾的省略号
+const lineClipTail = "…"
+
+// SetLineOverflow 设置超出终端宽度的行的处理方式，默认裁剪
+func (lu *printDiff) SetLineOverflow(overflow LineOverflow) {
+	lu.overflow = overflow
+}
+
+// SetViewportSize 手动设置可视区域的大小，为0的维度使用终端的大小，终端大小也获取不到时不限制
+func (lu *printDiff) SetViewportSize(size TermSize) {
+	lu.viewport = size
+}
+
+// ScrollTo 滚动到第 row 行（折行后的行），超出范围时自动修正，下次 SetLines 时生效
+func (lu *printDiff) ScrollTo(row int) {
+	lu.scroll = max(row, 0)
+}
+
+// ScrollBy 滚动 delta 行，正数向下，负数向上
+func (lu *printDiff) ScrollBy(delta int) {
+	lu.ScrollTo(lu.scroll + delta)
+}
+
+// PageDown 向下翻一页
+func (lu *printDiff) PageDown() {
+	lu.ScrollBy(max(lu.viewportSize().Rows, 1))
+}
+
+// PageUp 向上翻一页
+func (lu *printDiff) PageUp() {
+	lu.ScrollBy(-max(lu.viewportSize().Rows, 1))
+}
+
+// Redraw 按当前的滚动位置和终端大小重新输出
+func (lu *printDiff) Redraw() {
+	lu.SetLines(lu.lines)
+}
+
+// viewportSize 可视区域的大小，0表示该维度不限制
+func (lu *printDiff) viewportSize() TermSize {
+	size := lu.viewport
+	if (size.Cols > 0 && size.Rows > 0) || lu.term == nil {
+		return size
+	}
+	termSize, err := GetTerminalSize(lu.term)
+	if err != nil {
+		return size
+	}
+	if size.Cols <= 0 {
+		size.Cols = termSize.Cols
+	}
+	if size.Rows <= 0 {
+		size.Rows = termSize.Rows
+	}
+	return size
+}
+
+// layout 把逻辑行按宽度裁剪或折行，再截取可视区域内的行
+func (lu *printDiff) layout(lines []string, size TermSize) []string {
+	rows := lines
+	if size.Cols > 0 {
+		rows = make([]string, 0, len(lines))
+		for _, line := range lines {
+			if StrTerminalLen(line) <= size.Cols {
+				rows = append(rows, line)
+				continue
+			}
+			if lu.overflow == LineWrap {
+				rows = append(rows, StrWrap(line, size.Cols)...)
+			} else {
+				rows = append(rows, StrTruncate(line, size.Cols, lineClipTail))
+			}
+		}
+	}
+
+	if size.Rows <= 0 || len(rows) <= size.Rows {
+		lu.scroll = 0
+		return rows
+	}
+	lu.scroll = min(lu.scroll, len(rows)-size.Rows)
+	return rows[lu.scroll : lu.scroll+size.Rows]
+}
+
+// clearRegion 清除已输出的区域，光标停在区域第一行的行首
+// cols 为终端现在的宽度，按它估算原来的行被终端重新折行后实际占用的行数
+func (lu *printDiff) clearRegion(cols int) {
+	n := 0
+	for _, row := range lu.prevLines {
+		if w := StrTerminalLen(row); cols > 0 && w > cols {
+			n += (w + cols - 1) / cols
+		} else {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	if n > 1 {
+		fmt.Fprintf(lu.out, moveUp, n-1)
+	}
+	fmt.Fprint(lu.out, "\r"+clearToEnd)
+	lu.prevLines = lu.prevLines[:0]
+}
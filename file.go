@@ -2,12 +2,14 @@ package util
 
 import (
 	"encoding/csv"
-	"encoding/gob"
 	"fmt"
 	"log"
 	"os"
 )
 
+// AtomicWriteSmallFile 写入的临时文件后缀
+const atomicTmpSuffix = ".tmp"
+
 // FileExists 检查指定路径的文件是否存在
 // 参数:
 //
@@ -30,7 +32,7 @@ func FileExists(path string) (bool, error) {
 
 // 异步写文件。写完文件并关闭后，若在操作系统同步文件前断电，会丢失文件。
 func AtomicWriteSmallFile(file string, content []byte, perm os.FileMode) error {
-	tmpName := file + ".tmp"
+	tmpName := file + atomicTmpSuffix
 	if err := os.WriteFile(tmpName, content, perm); err != nil { // 覆盖式写入
 		return err
 	}
@@ -40,7 +42,7 @@ func AtomicWriteSmallFile(file string, content []byte, perm os.FileMode) error {
 // 同步写文件。写完文件后，让操作系统同步文件，然后再关闭文件，避免文件的丢失。
 // 同步操作会损耗性能
 func SyncAtomicWriteSmallFile(file string, content []byte, perm os.FileMode) error {
-	tmpName := file + ".tmp"
+	tmpName := file + atomicTmpSuffix
 
 	f, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
 	if err != nil {
@@ -59,28 +61,16 @@ func SyncAtomicWriteSmallFile(file string, content []byte, perm os.FileMode) err
 	return os.Rename(tmpName, file) // 对于目标已经存在，linux和mac直接覆盖，windows需要自己先删除后Rename
 }
 
+// SaveToCache 用gob编码写缓存，其他编码方式用 SaveToCacheWithCodec
 func SaveToCache[T any](data T, cacheFile string) error {
-	f, err := os.Create(cacheFile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	return gob.NewEncoder(f).Encode(data)
+	return SaveToCacheWithCodec(data, cacheFile, GobCodec, 0)
 }
 
 // 缓存是否过期，由外部的函数判断
 // 若文件不存在，会报错，用 if !os.IsNotExist(err) { 判断
+// 兼容没有文件头的旧缓存文件
 func LoadFromCache[T any](cacheFile string) (T, error) {
-	var data T
-
-	f, err := os.Open(cacheFile)
-	if err != nil {
-		return data, err
-	}
-	defer f.Close()
-
-	return data, gob.NewDecoder(f).Decode(&data)
+	return LoadFromCacheWithCodec[T](cacheFile, GobCodec, 0)
 }
 
 func SaveToCsv(data [][]string, csvFile string, readOnly bool) error {
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// 锁文件的后缀。锁加在旁路文件上而不是数据文件本身，
+	// 因为数据文件会被 os.Create 截断或被 Rename 替换（inode变化后锁就失效了）
+	lockFileSuffix = ".lock"
+
+	lockRetryMin = 5 * time.Millisecond   // 阻塞加锁时的最小轮询间隔
+	lockRetryMax = 200 * time.Millisecond // 阻塞加锁时的最大轮询间隔
+)
+
+// ErrFileLockUnsupported 当前平台不支持文件锁
+var ErrFileLockUnsupported = errors.New("file lock is not supported on this platform")
+
+// FileLock 跨进程的建议锁（advisory lock），基于 flock
+// 同一个 FileLock 同一时刻只能持有一把锁（共享或独占），不能重入
+// 建议锁只对同样使用锁的进程有效，不加锁直接读写文件的进程不受约束
+type FileLock struct {
+	path string
+	mu   sync.Mutex // 保护 f，避免同一进程内并发使用同一个 FileLock
+	f    *os.File
+}
+
+// NewFileLock 创建文件锁，path 是锁文件的路径（不存在时加锁时自动创建）
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// FileLockFor 获取保护 dataFile 的文件锁，锁文件为 dataFile + ".lock"
+func FileLockFor(dataFile string) *FileLock {
+	return NewFileLock(dataFile + lockFileSuffix)
+}
+
+// Path 锁文件路径
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Lock 阻塞获取独占锁（写锁），直到成功或ctx结束
+func (l *FileLock) Lock(ctx context.Context) error {
+	return l.lock(ctx, true)
+}
+
+// RLock 阻塞获取共享锁（读锁），直到成功或ctx结束
+func (l *FileLock) RLock(ctx context.Context) error {
+	return l.lock(ctx, false)
+}
+
+// TryLock 尝试获取独占锁，不阻塞。被其他进程占用时返回false
+func (l *FileLock) TryLock() (bool, error) {
+	return l.tryLock(true)
+}
+
+// TryRLock 尝试获取共享锁，不阻塞。被其他进程独占时返回false
+func (l *FileLock) TryRLock() (bool, error) {
+	return l.tryLock(false)
+}
+
+// Unlock 释放锁，未持有锁时直接返回nil
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f == nil {
+		return nil
+	}
+	err := flockUnlock(l.f)
+	if closeErr := l.f.Close(); err == nil { // 关闭文件也会释放锁，这里以解锁的错误为主
+		err = closeErr
+	}
+	l.f = nil
+	return err
+}
+
+// flock 没有带超时的阻塞方式，所以用非阻塞加锁 + 退避轮询来支持ctx
+func (l *FileLock) lock(ctx context.Context, exclusive bool) error {
+	wait := lockRetryMin
+	for {
+		ok, err := l.tryLock(exclusive)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("lock %s failed: %w", l.path, ctx.Err())
+		case <-timer.C:
+		}
+		wait = min(wait*2, lockRetryMax)
+	}
+}
+
+func (l *FileLock) tryLock(exclusive bool) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.f != nil {
+		return false, fmt.Errorf("lock %s is already held", l.path)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return false, err
+	}
+	ok, err := flockTry(f, exclusive)
+	if err != nil || !ok {
+		_ = f.Close()
+		return false, err
+	}
+	l.f = f
+	return true, nil
+}
+
+// withFileLock 持有 dataFile 对应的锁执行 job
+func withFileLock(ctx context.Context, dataFile string, exclusive bool, job func() error) error {
+	lock := FileLockFor(dataFile)
+	if err := lock.lock(ctx, exclusive); err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return job()
+}
+
+// SaveToCacheLocked 持有独占锁写缓存，与 LoadFromCacheLocked 配合避免跨进程读到写了一半的文件
+func SaveToCacheLocked[T any](ctx context.Context, data T, cacheFile string) error {
+	return withFileLock(ctx, cacheFile, true, func() error {
+		return SaveToCache(data, cacheFile)
+	})
+}
+
+// LoadFromCacheLocked 持有共享锁读缓存，多个读者可以同时读
+func LoadFromCacheLocked[T any](ctx context.Context, cacheFile string) (T, error) {
+	var data T
+	err := withFileLock(ctx, cacheFile, false, func() error {
+		var err error
+		data, err = LoadFromCache[T](cacheFile)
+		return err
+	})
+	return data, err
+}
+
+// SaveToCsvLocked 持有独占锁写csv，文件已存在时的行为同 SaveToCsv
+func SaveToCsvLocked(ctx context.Context, data [][]string, csvFile string, readOnly bool) error {
+	return withFileLock(ctx, csvFile, true, func() error {
+		return SaveToCsv(data, csvFile, readOnly)
+	})
+}
+
+// LoadFromCsvLocked 持有共享锁读csv
+func LoadFromCsvLocked(ctx context.Context, csvFile string) ([][]string, error) {
+	var data [][]string
+	err := withFileLock(ctx, csvFile, false, func() error {
+		var err error
+		data, err = LoadFromCsv(csvFile)
+		return err
+	})
+	return data, err
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package util
+
+import "os"
+
+func flockTry(f *os.File, exclusive bool) (bool, error) {
+	return false, ErrFileLockUnsupported
+}
+
+func flockUnlock(f *os.File) error {
+	return ErrFileLockUnsupported
+}
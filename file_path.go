@@ -0,0 +1,378 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// PathType 路径指向的对象类型
+type PathType int
+
+const (
+	PathNotExist PathType = iota // 路径不存在
+	PathFile                     // 普通文件
+	PathDir                      // 目录
+	PathSymlink                  // 符号链接（不跟随）
+	PathOther                    // 设备、管道、socket等
+)
+
+func (t PathType) String() string {
+	switch t {
+	case PathNotExist:
+		return "not exist"
+	case PathFile:
+		return "file"
+	case PathDir:
+		return "dir"
+	case PathSymlink:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
+
+// GetPathType 获取路径的类型，符号链接本身返回 PathSymlink，不跟随到目标
+// 与 FileExists 不同，FileExists 会跟随符号链接，且不区分文件和目录
+func GetPathType(p string) (PathType, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return PathNotExist, nil
+		}
+		return PathNotExist, err
+	}
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		return PathFile, nil
+	case mode.IsDir():
+		return PathDir, nil
+	case mode&os.ModeSymlink != 0:
+		return PathSymlink, nil
+	default:
+		return PathOther, nil
+	}
+}
+
+// IsFile 路径是否为普通文件（不跟随符号链接）
+func IsFile(p string) (bool, error) {
+	t, err := GetPathType(p)
+	return t == PathFile, err
+}
+
+// IsDir 路径是否为目录（不跟随符号链接）
+func IsDir(p string) (bool, error) {
+	t, err := GetPathType(p)
+	return t == PathDir, err
+}
+
+// IsSymlink 路径是否为符号链接
+func IsSymlink(p string) (bool, error) {
+	t, err := GetPathType(p)
+	return t == PathSymlink, err
+}
+
+// EnsureDir 确保目录存在，不存在时递归创建。路径已被文件占用时报错
+func EnsureDir(dir string, perm os.FileMode) error {
+	if err := os.MkdirAll(dir, perm); err != nil {
+		return err
+	}
+	info, err := os.Stat(dir) // MkdirAll 对已存在的符号链接目录也返回nil，这里跟随确认
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s exists but is not a directory", dir)
+	}
+	return nil
+}
+
+// CopyFile 复制文件，保留权限和修改时间。dst已存在时覆盖，dst与src是同一个文件时报错
+// src为符号链接时复制链接指向的内容
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+	// 同一个文件（包括通过符号链接、硬链接）用 O_TRUNC 打开会清空源文件
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(info, dstInfo) {
+		return fmt.Errorf("%s and %s are the same file", src, dst)
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return copyMetadata(dst, info)
+}
+
+// CopyDir 递归复制目录，保留权限和修改时间，符号链接复制为符号链接
+// dst不能已经存在，避免与已有内容混在一起
+func CopyDir(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !srcInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, 0700) // 先给自己写权限，目录内容复制完后再设置原权限
+		case d.Type()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return CopyFile(p, target)
+		default:
+			return fmt.Errorf("unsupported file type %s: %s", d.Type(), p)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// 目录的权限和时间在内容写完后设置，否则写入内容会改变目录的修改时间
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		return copyMetadata(filepath.Join(dst, rel), info)
+	})
+}
+
+func copyMetadata(dst string, info os.FileInfo) error {
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// MovePath 移动文件或目录。跨文件系统无法Rename时，先复制再删除源
+// 复制时先写到 dst 旁边的临时目录，完成后再Rename到 dst，失败时只删除自己写的内容，不影响 dst 原有的内容
+func MovePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	t, err := GetPathType(src)
+	if err != nil {
+		return err
+	}
+	if t == PathDir {
+		if _, err := os.Lstat(dst); err == nil {
+			return fmt.Errorf("%s already exists", dst)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".move-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir) // 复制了一半的内容不保留
+	tmp := filepath.Join(tmpDir, filepath.Base(dst))
+
+	switch t {
+	case PathDir:
+		err = CopyDir(src, tmp)
+	case PathSymlink:
+		var link string
+		if link, err = os.Readlink(src); err == nil {
+			err = os.Symlink(link, tmp)
+		}
+	default:
+		err = CopyFile(src, tmp)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// WalkFiles 递归列出 root 下的文件（不包含目录），返回的路径以 root 开头
+// 参数:
+//
+//	root - 根目录
+//	include - 文件需要匹配其中一个规则，为空时包含所有文件
+//	exclude - 匹配其中一个规则的文件被排除，匹配的目录整个跳过
+//
+// 注意:
+//
+//	规则使用 path.Match 的语法，匹配相对于 root 的路径（分隔符统一为/），支持 ** 匹配任意层目录
+//	规则中没有/时只匹配文件名，例如 *.csv 匹配任意层级的csv文件
+func WalkFiles(root string, include []string, exclude []string) ([]string, error) {
+	files := make([]string, 0, 16)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchAnyGlob(exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if len(include) == 0 || matchAnyGlob(include, rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// DirSize 目录下所有普通文件的大小之和，不跟随符号链接
+func DirSize(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// SafeRemove 删除 target（文件或整个目录），要求 target 位于 root 之内且不是 root 本身
+// 路径会解析符号链接后再比较，避免通过 .. 或符号链接删除 root 之外的内容
+func SafeRemove(root string, target string) error {
+	realRoot, err := realPath(root)
+	if err != nil {
+		return err
+	}
+	// target 本身可能是符号链接，只解析它所在的目录，删除的是链接而不是链接指向的内容
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+	realParent, err := realPath(filepath.Dir(absTarget))
+	if err != nil {
+		return err
+	}
+	realTarget := filepath.Join(realParent, filepath.Base(absTarget))
+
+	rel, err := filepath.Rel(realRoot, realTarget)
+	if err != nil {
+		return err
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("refuse to remove %s: not inside %s", target, root)
+	}
+	return os.RemoveAll(realTarget)
+}
+
+func realPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+			continue
+		}
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 按路径段匹配，** 匹配0个或多个路径段
+func matchGlob(pattern []string, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchGlob(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
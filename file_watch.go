@@ -0,0 +1,340 @@
+package util
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWatchDebounce     = 100 * time.Millisecond
+	defaultWatchPollInterval = time.Second
+)
+
+// WatchOp 文件变化的类型
+type WatchOp int
+
+const (
+	WatchCreate  WatchOp = iota + 1 // 新建
+	WatchModify                     // 内容变化
+	WatchDelete                     // 删除，或被移出监听的目录
+	WatchRename                     // 改名，OldPath 为改名前的路径
+	WatchReplace                    // 被整体替换，例如 AtomicWriteSmallFile 的 临时文件+Rename
+)
+
+func (op WatchOp) String() string {
+	switch op {
+	case WatchCreate:
+		return "create"
+	case WatchModify:
+		return "modify"
+	case WatchDelete:
+		return "delete"
+	case WatchRename:
+		return "rename"
+	case WatchReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// WatchEvent 文件变化事件
+type WatchEvent struct {
+	Path    string
+	OldPath string // 只有 WatchRename 才有
+	Op      WatchOp
+}
+
+// WatchOptions 监听的参数，零值使用默认参数
+type WatchOptions struct {
+	Debounce     time.Duration // 同一路径在该时间内的多次变化合并为一个事件，默认100ms
+	PollInterval time.Duration // 轮询模式的检查间隔，默认1s
+	Poll         bool          // 强制使用轮询模式，用于inotify不可用的场景（例如部分网络文件系统）
+}
+
+// WatchFiles 监听文件或目录的变化
+// 参数:
+//
+//	ctx - 结束后停止监听，并关闭返回的两个channel
+//	paths - 文件或目录。目录只监听直接子项（不递归），忽略其中 .tmp 后缀的临时文件
+//	opts - 监听参数
+//
+// 返回值:
+//
+//	<-chan WatchEvent - 合并（去抖）后的事件
+//	<-chan error - 监听过程中的错误，不读取时多余的错误会被丢弃
+//	error - 启动监听失败
+//
+// 注意:
+//
+//	linux 下使用 inotify，其他平台或 opts.Poll 为true时使用轮询
+//	文件可以在监听开始时还不存在，但它所在的目录必须存在
+//	轮询模式无法识别改名，表现为删除旧路径和新建新路径
+func WatchFiles(ctx context.Context, paths []string, opts WatchOptions) (<-chan WatchEvent, <-chan error, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = defaultWatchDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultWatchPollInterval
+	}
+
+	targets, err := newWatchTargets(paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := make(chan WatchEvent, 64)
+	errs := make(chan error, 8)
+	started := false
+	if !opts.Poll {
+		started = startNativeWatch(ctx, targets, raw, errs) == nil // 启动失败时退化为轮询
+	}
+	if !started {
+		if err := startPollWatch(ctx, targets, opts.PollInterval, raw, errs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	events := make(chan WatchEvent, 16)
+	go debounceWatchEvents(ctx, opts.Debounce, raw, events)
+	return events, errs, nil
+}
+
+// watchTargets 按目录组织监听对象，单个文件通过监听它所在的目录实现，这样文件被Rename替换后仍能继续监听
+type watchTargets map[string]map[string]bool // 目录 -> 文件名集合，集合为nil表示监听整个目录
+
+func newWatchTargets(paths []string) (watchTargets, error) {
+	targets := make(watchTargets, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if isDir, err := IsDir(abs); err != nil {
+			return nil, err
+		} else if isDir {
+			targets[abs] = nil
+			continue
+		}
+
+		dir, name := filepath.Split(abs)
+		dir = filepath.Clean(dir)
+		names, ok := targets[dir]
+		if ok && names == nil { // 已经监听了整个目录
+			continue
+		}
+		if names == nil {
+			names = make(map[string]bool)
+			targets[dir] = names
+		}
+		names[name] = true
+	}
+	return targets, nil
+}
+
+// match 路径是否在监听范围内
+func (t watchTargets) match(p string) bool {
+	dir, name := filepath.Split(p)
+	names, ok := t[filepath.Clean(dir)]
+	if !ok {
+		return false
+	}
+	if names == nil {
+		return !strings.HasSuffix(name, atomicTmpSuffix)
+	}
+	return names[name]
+}
+
+// sendWatchError 不阻塞地发送错误，调用方不读取时丢弃
+func sendWatchError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
+
+// renameWatchEvent 根据改名前后的路径生成事件
+func renameWatchEvent(oldPath, newPath string) WatchEvent {
+	if oldPath == newPath+atomicTmpSuffix {
+		return WatchEvent{Path: newPath, Op: WatchReplace}
+	}
+	return WatchEvent{Path: newPath, OldPath: oldPath, Op: WatchRename}
+}
+
+func startPollWatch(ctx context.Context, targets watchTargets, interval time.Duration, raw chan<- WatchEvent, errs chan error) error {
+	prev, err := pollWatchSnapshot(targets)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer close(errs)
+		defer close(raw)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			curr, err := pollWatchSnapshot(targets)
+			if err != nil {
+				sendWatchError(errs, err)
+				continue
+			}
+			for p, info := range curr {
+				old, ok := prev[p]
+				var op WatchOp
+				switch {
+				case !ok:
+					op = WatchCreate
+				case !os.SameFile(old, info): // inode变化，被替换了
+					op = WatchReplace
+				case !old.ModTime().Equal(info.ModTime()) || old.Size() != info.Size():
+					op = WatchModify
+				default:
+					continue
+				}
+				select {
+				case raw <- WatchEvent{Path: p, Op: op}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			for p := range prev {
+				if _, ok := curr[p]; ok {
+					continue
+				}
+				select {
+				case raw <- WatchEvent{Path: p, Op: WatchDelete}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = curr
+		}
+	}()
+	return nil
+}
+
+func pollWatchSnapshot(targets watchTargets) (map[string]os.FileInfo, error) {
+	snapshot := make(map[string]os.FileInfo)
+	for dir, names := range targets {
+		if names != nil {
+			for name := range names {
+				p := filepath.Join(dir, name)
+				info, err := os.Stat(p)
+				if err != nil {
+					if os.IsNotExist(err) {
+						continue
+					}
+					return nil, err
+				}
+				snapshot[p] = info
+			}
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			p := filepath.Join(dir, entry.Name())
+			if !targets.match(p) {
+				continue
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			snapshot[p] = info
+		}
+	}
+	return snapshot, nil
+}
+
+// debounceWatchEvents 合并同一路径的连续事件，在 debounce 时间内没有新事件后按发生顺序输出
+func debounceWatchEvents(ctx context.Context, debounce time.Duration, raw <-chan WatchEvent, events chan<- WatchEvent) {
+	defer close(events)
+
+	pending := make(map[string]WatchEvent)
+	order := make([]string, 0, 16)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() bool {
+		for _, p := range order {
+			event, ok := pending[p]
+			if !ok {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		clear(pending)
+		order = order[:0]
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-raw:
+			if !ok {
+				flush()
+				return
+			}
+			prev, exists := pending[event.Path]
+			if !exists {
+				order = append(order, event.Path)
+			}
+			if merged, keep := mergeWatchEvent(prev, exists, event); keep {
+				pending[event.Path] = merged
+			} else {
+				// 相互抵消，同时从顺序中去掉，之后的事件重新排在最后
+				delete(pending, event.Path)
+				order = slices.DeleteFunc(order, func(p string) bool { return p == event.Path })
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			if !flush() {
+				return
+			}
+		}
+	}
+}
+
+// mergeWatchEvent 合并同一路径的两个事件，返回false表示两个事件相互抵消
+func mergeWatchEvent(prev WatchEvent, exists bool, next WatchEvent) (WatchEvent, bool) {
+	if !exists {
+		return next, true
+	}
+	switch {
+	case prev.Op == WatchCreate && next.Op == WatchDelete: // 临时出现又消失
+		return next, false
+	case prev.Op == WatchCreate && next.Op == WatchModify:
+		return prev, true
+	case prev.Op == WatchDelete && next.Op == WatchCreate:
+		return WatchEvent{Path: next.Path, Op: WatchReplace}, true
+	case (prev.Op == WatchReplace || prev.Op == WatchRename) && next.Op == WatchModify:
+		return prev, true
+	default:
+		return next, true
+	}
+}
@@ -0,0 +1,130 @@
+//go:build linux
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyWatchMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// startNativeWatch 基于inotify监听，返回错误时由调用方退化为轮询
+func startNativeWatch(ctx context.Context, targets watchTargets, raw chan<- WatchEvent, errs chan error) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+	// 非阻塞的fd交给os.File后由runtime的poller等待，Close可以打断正在进行的Read
+	f := os.NewFile(uintptr(fd), "inotify")
+
+	dirs := make(map[int32]string, len(targets))
+	for dir := range targets {
+		wd, err := syscall.InotifyAddWatch(fd, dir, inotifyWatchMask)
+		if err != nil {
+			_ = f.Close()
+			return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+		dirs[int32(wd)] = dir
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = f.Close()
+	}()
+
+	go func() {
+		defer close(errs)
+		defer close(raw)
+
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					sendWatchError(errs, err)
+				}
+				return
+			}
+			for _, event := range parseInotifyEvents(buf[:n], dirs, targets, errs) {
+				select {
+				case raw <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// parseInotifyEvents 解析一次Read得到的事件，并配对同一次Rename的 MOVED_FROM 和 MOVED_TO
+func parseInotifyEvents(buf []byte, dirs map[int32]string, targets watchTargets, errs chan<- error) []WatchEvent {
+	events := make([]WatchEvent, 0, 8)
+	movedFrom := make(map[uint32]string) // cookie -> 改名前的路径
+	fromOrder := make([]uint32, 0, 2)
+
+	add := func(event WatchEvent) {
+		if targets.match(event.Path) || (event.OldPath != "" && targets.match(event.OldPath)) {
+			events = append(events, event)
+		}
+	}
+
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+		offset += syscall.SizeofInotifyEvent + int(raw.Len)
+
+		mask := raw.Mask
+		if mask&syscall.IN_Q_OVERFLOW != 0 {
+			sendWatchError(errs, fmt.Errorf("inotify queue overflow, some events are lost"))
+			continue
+		}
+		dir, ok := dirs[raw.Wd]
+		if !ok {
+			continue
+		}
+		if mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 { // 监听的目录本身没了
+			events = append(events, WatchEvent{Path: dir, Op: WatchDelete})
+			continue
+		}
+		name := string(bytes.TrimRight(nameBytes, "\x00"))
+		if name == "" {
+			continue
+		}
+		p := filepath.Join(dir, name)
+
+		switch {
+		case mask&syscall.IN_CREATE != 0:
+			add(WatchEvent{Path: p, Op: WatchCreate})
+		case mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE) != 0:
+			add(WatchEvent{Path: p, Op: WatchModify})
+		case mask&syscall.IN_DELETE != 0:
+			add(WatchEvent{Path: p, Op: WatchDelete})
+		case mask&syscall.IN_MOVED_FROM != 0:
+			movedFrom[raw.Cookie] = p
+			fromOrder = append(fromOrder, raw.Cookie)
+		case mask&syscall.IN_MOVED_TO != 0:
+			if oldPath, ok := movedFrom[raw.Cookie]; ok {
+				delete(movedFrom, raw.Cookie)
+				add(renameWatchEvent(oldPath, p))
+			} else { // 从监听范围外移入
+				add(WatchEvent{Path: p, Op: WatchCreate})
+			}
+		}
+	}
+
+	// 没有配对的 MOVED_FROM 是被移出了监听的目录
+	for _, cookie := range fromOrder {
+		if p, ok := movedFrom[cookie]; ok {
+			add(WatchEvent{Path: p, Op: WatchDelete})
+		}
+	}
+	return events
+}
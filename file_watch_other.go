@@ -0,0 +1,12 @@
+//go:build !linux
+
+package util
+
+import (
+	"context"
+	"errors"
+)
+
+func startNativeWatch(ctx context.Context, targets watchTargets, raw chan<- WatchEvent, errs chan error) error {
+	return errors.New("native file watch is not supported on this platform")
+}
@@ -0,0 +1,315 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultLiveRefresh = 100 * time.Millisecond
+
+// LiveRegion 终端底部的实时刷新区域，基于 printDiff 只重绘变化的部分
+// 所有方法并发安全，可以在刷新区域的同时用 Println 输出普通日志
+// 输出不是终端时不使用转义序列：区域中变化了的行和日志都按普通的行输出
+type LiveRegion struct {
+	mu       sync.Mutex
+	out      io.Writer
+	buf      bytes.Buffer // printDiff 先输出到这里，再一次性写入 out，避免和其他输出交错
+	diff     *printDiff
+	interval time.Duration
+	lines    []string // 最新设置的内容
+	dirty    bool     // lines 是否还没有输出
+	lastDraw time.Time
+	timer    *time.Timer // 被节流推迟的刷新
+	plain    bool        // 输出不是终端
+	started  bool
+	closed   bool
+	exitSig  bool // 恢复光标后重新发送信号，让进程按默认方式退出，默认true
+
+	sigCh   chan os.Signal
+	sigDone chan struct{}
+}
+
+// NewLiveRegion 创建实时刷新区域
+// 参数:
+//
+//	out - 输出目标，一般为 os.Stdout 或 os.Stderr
+//	refreshInterval - 两次刷新的最小间隔，期间多次 SetLines 只输出最后一次的内容，<=0 时为100ms
+func NewLiveRegion(out io.Writer, refreshInterval time.Duration) *LiveRegion {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultLiveRefresh
+	}
+	r := &LiveRegion{
+		out:      out,
+		interval: refreshInterval,
+		plain:    !isTerminalWriter(out),
+		exitSig:  true,
+	}
+	// printDiff 输出到缓冲区，是否为终端和终端的大小以 out 为准
+	r.diff = newPrintDiff(&r.buf)
+	r.diff.plain = r.plain
+	r.diff.term, _ = out.(*os.File)
+	return r
+}
+
+// Start 隐藏光标，并在收到 SIGINT/SIGTERM 时恢复光标、停止刷新，然后重新发送信号，进程按原来的方式退出
+// 程序有自己的信号处理（如 signal.NotifyContext）时，用 SetExitOnSignal(false) 只恢复终端，由程序决定如何退出
+// 区域从当前光标所在的行开始，不清屏
+func (r *LiveRegion) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.started || r.closed || r.plain {
+		return
+	}
+	r.started = true
+	r.write(hideCursor)
+
+	r.sigCh = make(chan os.Signal, 1)
+	r.sigDone = make(chan struct{})
+	signal.Notify(r.sigCh, os.Interrupt, syscall.SIGTERM)
+	go r.waitSignal()
+	if r.diff.term != nil {
+		go r.waitResize()
+	}
+}
+
+// SetExitOnSignal 收到 SIGINT/SIGTERM 恢复光标后，是否重新发送信号使进程按默认方式退出，默认true
+// 程序自己也处理了这些信号时关闭，否则程序会再收到一次信号（很多程序把第二次 Ctrl-C 当作强制退出）
+// 关闭后之后再收到的信号按默认方式处理
+func (r *LiveRegion) SetExitOnSignal(exit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exitSig = exit
+}
+
+// SetLineOverflow 设置超出终端宽度的行的处理方式，见 printDiff.SetLineOverflow
+func (r *LiveRegion) SetLineOverflow(overflow LineOverflow) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.SetLineOverflow(overflow)
+	r.redraw()
+}
+
+// ScrollTo 内容超过终端高度时，滚动到第 row 行（折行后的行）
+func (r *LiveRegion) ScrollTo(row int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.ScrollTo(row)
+	r.redraw()
+}
+
+// ScrollBy 滚动 delta 行，正数向下，负数向上
+func (r *LiveRegion) ScrollBy(delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.ScrollBy(delta)
+	r.redraw()
+}
+
+// PageDown 向下翻一页
+func (r *LiveRegion) PageDown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.PageDown()
+	r.redraw()
+}
+
+// PageUp 向上翻一页
+func (r *LiveRegion) PageUp() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.PageUp()
+	r.redraw()
+}
+
+// SetHighlightStyles 设置变化内容的高亮样式，见 printDiff.SetHighlightStyles
+func (r *LiveRegion) SetHighlightStyles(added Style, changed Style) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.SetHighlightStyles(added, changed)
+}
+
+// SetHighlightFade 高亮在之后的 refreshes 次刷新后消失，<=0 表示保持到该行再次变化
+func (r *LiveRegion) SetHighlightFade(refreshes int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diff.SetHighlightFade(refreshes)
+}
+
+// SetLines 设置区域的全部内容，按刷新间隔节流输出
+func (r *LiveRegion) SetLines(lines []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.lines = append(r.lines[:0], lines...)
+	r.dirty = true
+
+	wait := r.interval - time.Since(r.lastDraw)
+	if wait <= 0 {
+		r.draw()
+		return
+	}
+	if r.timer == nil {
+		r.timer = time.AfterFunc(wait, r.Flush)
+	}
+}
+
+// Println 在区域上方输出一行普通日志，区域随之下移
+func (r *LiveRegion) Println(a ...any) {
+	r.print(fmt.Sprintln(a...))
+}
+
+// Printf 在区域上方输出普通日志，没有以换行结尾时自动补上
+func (r *LiveRegion) Printf(format string, a ...any) {
+	r.print(fmt.Sprintf(format, a...))
+}
+
+// Flush 立即输出被节流推迟的内容
+func (r *LiveRegion) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if r.dirty && !r.closed {
+		r.draw()
+	}
+}
+
+// Close 输出最后的内容，恢复光标。区域的内容保留在屏幕上
+func (r *LiveRegion) Close() {
+	r.Flush()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	r.closed = true
+	if r.started {
+		signal.Stop(r.sigCh)
+		close(r.sigDone)
+	}
+	r.restore()
+}
+
+func (r *LiveRegion) print(text string) {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.plain {
+		r.write(text)
+		return
+	}
+
+	// 清除区域，在区域原来的位置输出日志，再在日志下方完整重绘区域
+	r.clear()
+	r.write(text)
+	if len(r.lines) > 0 {
+		r.draw()
+	}
+}
+
+// clear 清除已输出的区域，光标停在区域的第一行行首。调用方持有 mu
+func (r *LiveRegion) clear() {
+	r.diff.clearRegion(r.diff.lastSize.Cols)
+	r.flushBuf()
+}
+
+// draw 输出最新内容。调用方持有 mu
+func (r *LiveRegion) draw() {
+	r.diff.SetLines(r.lines)
+	r.flushBuf()
+	r.dirty = false
+	r.lastDraw = time.Now()
+}
+
+// redraw 按当前内容重新输出，还没有输出过时不输出。调用方持有 mu
+func (r *LiveRegion) redraw() {
+	if r.closed || len(r.diff.prevLines) == 0 {
+		return
+	}
+	r.draw()
+}
+
+// restore 光标移到区域下方并显示光标。调用方持有 mu
+func (r *LiveRegion) restore() {
+	if r.plain {
+		return
+	}
+	if len(r.diff.prevLines) > 0 {
+		r.write("\n")
+	}
+	r.write(showCursor)
+}
+
+func (r *LiveRegion) write(s string) {
+	r.buf.WriteString(s)
+	r.flushBuf()
+}
+
+func (r *LiveRegion) flushBuf() {
+	_, _ = r.out.Write(r.buf.Bytes())
+	r.buf.Reset()
+}
+
+// waitSignal 收到退出信号时恢复光标并停止刷新，再恢复信号的默认处理
+// 没有 SetExitOnSignal(false) 时重新发送信号，让进程按原来的方式退出
+func (r *LiveRegion) waitSignal() {
+	select {
+	case sig := <-r.sigCh:
+		r.mu.Lock()
+		if !r.closed {
+			r.closed = true
+			r.restore()
+			close(r.sigDone) // Close 不会再执行，在这里结束 waitResize
+		}
+		exit := r.exitSig
+		r.mu.Unlock()
+
+		signal.Stop(r.sigCh)
+		if !exit {
+			return
+		}
+		if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+			return
+		}
+		os.Exit(1)
+	case <-r.sigDone:
+	}
+}
+
+// waitResize 终端大小变化时立即重绘，不等下一次 SetLines
+func (r *LiveRegion) waitResize() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resize := WatchTerminalResize(ctx, r.diff.term)
+	for {
+		select {
+		case <-resize:
+			r.mu.Lock()
+			r.redraw()
+			r.mu.Unlock()
+		case <-r.sigDone:
+			return
+		}
+	}
+}
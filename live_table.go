@@ -0,0 +1,200 @@
+package util
+
+import (
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LiveTable 实时刷新的表格，通过 LiveRegion 只重绘变化的部分
+// 列宽只增不减，数据变化时表格不会抖动；变化了的单元格（而不是整行）高亮显示
+// 行通过键列（默认第0列）对应到上次的数据，排序或插入行后也只高亮真正变化的单元格
+type LiveTable struct {
+	mu     sync.Mutex
+	region *LiveRegion
+	table  *Table // 模板的副本
+	widths []int  // 历史最大的列宽
+
+	keyCol    int
+	sortCol   int // <0 表示不排序
+	sortDesc  bool
+	highlight Style
+	fadeAfter int // 高亮保持的更新次数
+
+	update  int                 // Update 的次数
+	cells   map[string][]string // 键 -> 上次的单元格
+	changed map[string][]int    // 键 -> 各单元格最后变化时的更新次数
+}
+
+// NewLiveTable 创建实时刷新的表格
+// 参数:
+//
+//	out - 输出目标，一般为 os.Stdout
+//	table - 表格的模板：表头、表尾、边框、对齐、最大宽度、固定列宽等，内容由 Update 设置
+//	        创建时复制，之后对 table 的修改不影响实时表格
+func NewLiveTable(out io.Writer, table *Table) *LiveTable {
+	// 复制模板，切片也要复制：SetAlign 等方法原地修改切片，不能与调用方的表格共用
+	template := *table
+	template.header = slices.Clone(table.header)
+	template.footer = slices.Clone(table.footer)
+	template.aligns = slices.Clone(table.aligns)
+	template.maxWidths = slices.Clone(table.maxWidths)
+	template.colWidths = slices.Clone(table.colWidths)
+	region := NewLiveRegion(out, 0)
+	// 高亮由表格按单元格处理，关闭 printDiff 按字符的高亮
+	region.SetHighlightStyles(NewStyle(), NewStyle())
+	return &LiveTable{
+		region:    region,
+		table:     &template,
+		sortCol:   -1,
+		highlight: NewStyle().Reverse(),
+		fadeAfter: 1,
+		cells:     make(map[string][]string),
+		changed:   make(map[string][]int),
+	}
+}
+
+// SetKeyColumn 设置用于识别行的列，默认第0列
+func (t *LiveTable) SetKeyColumn(col int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keyCol = col
+}
+
+// SetHighlight 设置变化单元格的高亮样式，默认反色，传入 NewStyle() 表示不高亮
+func (t *LiveTable) SetHighlight(style Style) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.highlight = style
+}
+
+// SetHighlightFade 高亮保持的 Update 次数，默认1，即下次 Update 时未变化的单元格不再高亮
+func (t *LiveTable) SetHighlightFade(updates int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fadeAfter = max(updates, 1)
+}
+
+// SortBy 按第 col 列排序，两个单元格都是数字时按数值比较，否则按字符串比较；col<0 表示保持 Update 的顺序
+// 排序是稳定的，在下次 Update 时生效
+func (t *LiveTable) SortBy(col int, desc bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sortCol = col
+	t.sortDesc = desc
+}
+
+// Start 开始刷新，见 LiveRegion.Start
+func (t *LiveTable) Start() {
+	t.region.Start()
+}
+
+// SetExitOnSignal 见 LiveRegion.SetExitOnSignal
+func (t *LiveTable) SetExitOnSignal(exit bool) {
+	t.region.SetExitOnSignal(exit)
+}
+
+// Println 在表格上方输出日志
+func (t *LiveTable) Println(a ...any) {
+	t.region.Println(a...)
+}
+
+// Close 输出最后的内容，恢复光标
+func (t *LiveTable) Close() {
+	t.region.Close()
+}
+
+// Update 设置表格的全部内容并刷新
+func (t *LiveTable) Update(rows [][]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rows = append([][]string(nil), rows...)
+	if t.sortCol >= 0 {
+		sortTableRows(rows, t.sortCol, t.sortDesc)
+	}
+	t.update++
+
+	// 第一次 Update 的内容都是新的，不高亮
+	highlight := t.update > 1 && !t.highlight.IsZero() && !t.region.plain
+	cells := make(map[string][]string, len(rows))
+	changed := make(map[string][]int, len(rows))
+	styled := make([][]string, len(rows))
+	for i, row := range rows {
+		key := tableRowKey(row, t.keyCol)
+		prev, ok := t.cells[key]
+		marks := make([]int, len(row))
+		copy(marks, t.changed[key])
+		for k, cell := range row {
+			if !ok || k >= len(prev) || prev[k] != cell {
+				marks[k] = t.update
+			}
+		}
+		cells[key] = row
+		changed[key] = marks
+
+		styled[i] = row
+		if !highlight {
+			continue
+		}
+		styled[i] = make([]string, len(row))
+		for k, cell := range row {
+			if t.update-marks[k] < t.fadeAfter && cell != "" {
+				cell = t.highlight.RenderFor(t.region.out, cell)
+			}
+			styled[i][k] = cell
+		}
+	}
+	t.cells = cells
+	t.changed = changed
+
+	// 列宽只增不减，模板设置了固定列宽时使用固定列宽
+	view := *t.table
+	view.rows = rows
+	if view.colWidths == nil {
+		widths := view.widths(view.colCount())
+		for k := range widths {
+			if k < len(t.widths) {
+				widths[k] = max(widths[k], t.widths[k])
+			}
+		}
+		t.widths = widths
+		view.colWidths = widths
+	}
+
+	view.rows = styled
+	t.region.SetLines(view.Lines())
+}
+
+// tableRowKey 行的键，行中没有键列时使用整行的内容
+func tableRowKey(row []string, keyCol int) string {
+	if keyCol >= 0 && keyCol < len(row) {
+		return row[keyCol]
+	}
+	return strings.Join(row, "\x00")
+}
+
+// sortTableRows 按第 col 列稳定排序，数字按数值比较
+func sortTableRows(rows [][]string, col int, desc bool) {
+	cell := func(row []string) string {
+		if col < len(row) {
+			return StripANSI(row[col])
+		}
+		return ""
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := cell(rows[i]), cell(rows[j])
+		if desc {
+			a, b = b, a
+		}
+		fa, errA := strconv.ParseFloat(strings.ReplaceAll(a, ",", ""), 64)
+		fb, errB := strconv.ParseFloat(strings.ReplaceAll(b, ",", ""), 64)
+		if errA == nil && errB == nil {
+			return fa < fb
+		}
+		return a < b
+	})
+}
@@ -0,0 +1,213 @@
+package util
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// Pipeline 由channel连接的多级处理流程，各级并发运行
+// 任意一级出错（包括panic）时取消整个流程，Wait 返回第一个错误
+// 例子：抓取页面 -> 解析链接 -> 保存
+//
+//	p := NewPipeline(ctx)
+//	urls := Source(p, slices.Values(startUrls))
+//	pages := Stage(p, urls, 8, fetch)
+//	links := FlatStage(p, pages, 2, parseLinks)
+//	Sink(p, links, 1, save)
+//	err := p.Wait()
+//
+// 最后一级的输出必须被读完（Sink、Collect 或自己读取），否则上游会阻塞到流程被取消
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once sync.Once
+	err  error
+}
+
+// NewPipeline 创建流程，ctx 取消时所有阶段退出
+func NewPipeline(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context 流程的 ctx，出错或 Wait 返回后被取消
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Wait 等待所有阶段结束，返回第一个错误；没有出错但 ctx 被取消时返回 ctx.Err()
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	ctxErr := p.ctx.Err()
+	p.cancel()
+	p.once.Do(func() { p.err = ctxErr })
+	return p.err
+}
+
+func (p *Pipeline) fail(err error) {
+	p.once.Do(func() { p.err = err })
+	p.cancel()
+}
+
+// run 启动一个协程，panic转换为错误，出错时取消流程
+func (p *Pipeline) run(fn func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := callRecover(fn); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// send 发送到 out，流程取消时返回false
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Source 把序列作为流程的输入，切片可以用 slices.Values 转换
+func Source[T any](p *Pipeline, items iter.Seq[T]) <-chan T {
+	out := make(chan T)
+	p.run(func() error {
+		defer close(out)
+		for v := range items {
+			if !send(p.ctx, out, v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Stage 用 workers 个协程并发处理 in 中的元素（扇出），结果汇总到一个channel（扇入），输出的顺序不保证
+// fn 返回错误时取消整个流程
+func Stage[In any, Out any](p *Pipeline, in <-chan In, workers int, fn func(ctx context.Context, v In) (Out, error)) <-chan Out {
+	return FlatStage(p, in, workers, func(ctx context.Context, v In, emit func(Out) bool) error {
+		result, err := fn(ctx, v)
+		if err != nil {
+			return err
+		}
+		emit(result)
+		return nil
+	})
+}
+
+// FlatStage 同 Stage，但每个元素可以输出0到多个结果，用于过滤或展开（如一个页面解析出多个链接）
+// emit 返回false表示流程已取消，应尽快返回
+func FlatStage[In any, Out any](p *Pipeline, in <-chan In, workers int, fn func(ctx context.Context, v In, emit func(Out) bool) error) <-chan Out {
+	out := make(chan Out)
+	emit := func(v Out) bool {
+		return send(p.ctx, out, v)
+	}
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		p.run(func() error {
+			defer wg.Done()
+			for {
+				select {
+				case <-p.ctx.Done():
+					return nil
+				case v, ok := <-in:
+					if !ok {
+						return nil
+					}
+					if err := fn(p.ctx, v, emit); err != nil {
+						return err
+					}
+				}
+			}
+		})
+	}
+	// 所有工作协程结束后关闭输出
+	p.run(func() error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// Sink 用 workers 个协程消费 in 中的元素，是流程的最后一级
+func Sink[T any](p *Pipeline, in <-chan T, workers int, fn func(ctx context.Context, v T) error) {
+	FlatStage(p, in, workers, func(ctx context.Context, v T, emit func(struct{}) bool) error {
+		return fn(ctx, v)
+	})
+}
+
+// Collect 读取 in 中的所有元素，阻塞到 in 关闭或流程取消，之后调用 Wait 获取错误
+func Collect[T any](p *Pipeline, in <-chan T) []T {
+	results := make([]T, 0)
+	for {
+		select {
+		case <-p.ctx.Done():
+			return results
+		case v, ok := <-in:
+			if !ok {
+				return results
+			}
+			results = append(results, v)
+		}
+	}
+}
+
+// Merge 把多个channel合并为一个（扇入），所有输入关闭后关闭输出
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		p.run(func() error {
+			defer wg.Done()
+			for {
+				select {
+				case <-p.ctx.Done():
+					return nil
+				case v, ok := <-in:
+					if !ok || !send(p.ctx, out, v) {
+						return nil
+					}
+				}
+			}
+		})
+	}
+	p.run(func() error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// FanOut 把 in 中的元素分发到 n 个channel（每个元素只发到其中一个，哪个空闲发给哪个），用于给各个分支接不同的处理
+func FanOut[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	outs := make([]<-chan T, 0, max(n, 1))
+	for range max(n, 1) {
+		out := make(chan T)
+		outs = append(outs, out)
+		p.run(func() error {
+			defer close(out)
+			for {
+				select {
+				case <-p.ctx.Done():
+					return nil
+				case v, ok := <-in:
+					if !ok || !send(p.ctx, out, v) {
+						return nil
+					}
+				}
+			}
+		})
+	}
+	return outs
+}
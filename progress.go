@@ -0,0 +1,366 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProgressBarWidth = 30
+	progressRefresh         = 100 * time.Millisecond
+	progressPlainInterval   = 5 * time.Second // 不是终端时输出进度的间隔
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// FormatBytes 把字节数格式化为 B、KiB、MiB、GiB、TiB
+// 例子：1536 => 1.50 KiB
+func FormatBytes(n int64) string {
+	units := []string{" KiB", " MiB", " GiB", " TiB", " PiB"}
+	if n < 1024 && n > -1024 {
+		return Int2String(int(n), " B")
+	}
+	value := float64(n)
+	unit := ""
+	for _, u := range units {
+		value /= 1024
+		unit = u
+		if math.Abs(value) < 1024 {
+			break
+		}
+	}
+	return Float2String(value, unit)
+}
+
+// formatClock 把时长格式化为时钟的形式，例如 05:09、1:02:03
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	s := int(d % time.Minute / time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// ProgressBar 有总量的进度条，并发安全
+// 输出例子：下载 [=============>----------------]  45.00% 12.15 MiB/27.00 MiB 1.20 MiB/s 剩余 00:12
+type ProgressBar struct {
+	mu      sync.Mutex
+	title   string
+	total   int64
+	current int64
+	bytes   bool // 数量和速度按字节格式化
+	width   int  // 进度条的字符数
+	start   time.Time
+	end     time.Time // 完成的时间，零值表示未完成
+}
+
+// NewProgressBar 创建进度条，bytes 为true时数量和速度按字节格式化
+// 一般通过 ProgressGroup.AddBar 创建并输出，也可以用 String 自行输出
+func NewProgressBar(title string, total int64, bytes bool) *ProgressBar {
+	return &ProgressBar{
+		title: title,
+		total: total,
+		bytes: bytes,
+		width: defaultProgressBarWidth,
+		start: time.Now(),
+	}
+}
+
+// Add 增加进度，可以直接作为 io.Writer 的计数使用，见 Write
+func (b *ProgressBar) Add(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(b.current + n)
+}
+
+// Set 设置当前进度
+func (b *ProgressBar) Set(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(n)
+}
+
+// SetTotal 修改总量，例如下载时才知道文件大小
+func (b *ProgressBar) SetTotal(total int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total = total
+	b.setLocked(b.current)
+}
+
+// Write 按写入的字节数增加进度，配合 io.TeeReader、io.MultiWriter 统计读写的数据量
+func (b *ProgressBar) Write(p []byte) (int, error) {
+	b.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Done 标记完成，进度设为总量
+func (b *ProgressBar) Done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(max(b.current, b.total))
+}
+
+func (b *ProgressBar) setLocked(n int64) {
+	b.current = n
+	if b.total > 0 && b.current >= b.total && b.end.IsZero() {
+		b.end = time.Now()
+	}
+}
+
+// IsDone 是否已完成
+func (b *ProgressBar) IsDone() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.end.IsZero()
+}
+
+// String 进度条当前的文字
+func (b *ProgressBar) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ratio := 0.0
+	if b.total > 0 {
+		ratio = min(float64(b.current)/float64(b.total), 1)
+	}
+	filled := int(ratio * float64(b.width))
+	bar := strings.Repeat("=", filled)
+	if filled < b.width {
+		bar += ">" + strings.Repeat("-", b.width-filled-1)
+	}
+
+	now := time.Now()
+	if !b.end.IsZero() {
+		now = b.end
+	}
+	elapsed := now.Sub(b.start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(b.current) / elapsed.Seconds()
+	}
+
+	var sb strings.Builder
+	if b.title != "" {
+		sb.WriteString(b.title + " ")
+	}
+	sb.WriteString("[" + bar + "] ")
+	sb.WriteString(StrPadLeft(Float2String(ratio*100, "%"), 7))
+	sb.WriteString(" " + b.formatCount(float64(b.current), "") + "/" + b.formatCount(float64(b.total), ""))
+	sb.WriteString(" " + b.formatCount(rate, "/s"))
+	switch {
+	case !b.end.IsZero():
+		sb.WriteString(" 用时 " + formatClock(elapsed))
+	case rate > 0 && b.total > b.current:
+		eta := time.Duration(float64(b.total-b.current) / rate * float64(time.Second))
+		sb.WriteString(" 剩余 " + formatClock(eta))
+	default:
+		sb.WriteString(" 剩余 --:--")
+	}
+	return sb.String()
+}
+
+func (b *ProgressBar) formatCount(n float64, suffix string) string {
+	if b.bytes {
+		return FormatBytes(int64(n)) + suffix
+	}
+	if n == math.Trunc(n) {
+		return Int2String(int(n), suffix)
+	}
+	return Float2String(n, suffix)
+}
+
+// Spinner 没有总量的进度，显示转动的图标和已用时间，并发安全
+type Spinner struct {
+	mu      sync.Mutex
+	title   string
+	message string
+	start   time.Time
+	end     time.Time // 完成的时间，零值表示未完成
+}
+
+// NewSpinner 创建转动图标，一般通过 ProgressGroup.AddSpinner 创建并输出
+func NewSpinner(title string) *Spinner {
+	return &Spinner{title: title, start: time.Now()}
+}
+
+// SetMessage 设置显示在标题后面的状态信息
+func (s *Spinner) SetMessage(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.message = message
+}
+
+// Done 标记完成，message 为完成后显示的信息
+func (s *Spinner) Done(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.message = message
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+}
+
+// IsDone 是否已完成
+func (s *Spinner) IsDone() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.end.IsZero()
+}
+
+// String 当前的文字
+func (s *Spinner) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	icon := "✓"
+	now := s.end
+	if now.IsZero() {
+		now = time.Now()
+		icon = spinnerFrames[int(now.Sub(s.start)/progressRefresh)%len(spinnerFrames)]
+	}
+	text := icon + " " + s.title
+	if s.message != "" {
+		text += " " + s.message
+	}
+	return text + " (" + formatClock(now.Sub(s.start)) + ")"
+}
+
+// progressItem ProgressBar 或 Spinner
+type progressItem interface {
+	String() string
+	IsDone() bool
+}
+
+// ProgressGroup 一组进度（多个进度条、转动图标），通过 LiveRegion 定时刷新，适合并发任务
+// 输出不是终端时，每隔5秒以及全部完成时输出一次所有进度的文字
+type ProgressGroup struct {
+	region *LiveRegion
+
+	mu        sync.Mutex
+	items     []progressItem
+	lastPlain time.Time // 不是终端时上次输出的时间
+	running   bool
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewProgressGroup 创建进度组，out 一般为 os.Stderr，避免和程序的正常输出混在一起
+func NewProgressGroup(out io.Writer) *ProgressGroup {
+	return &ProgressGroup{
+		region: NewLiveRegion(out, progressRefresh),
+	}
+}
+
+// AddBar 添加进度条
+func (g *ProgressGroup) AddBar(title string, total int64, bytes bool) *ProgressBar {
+	bar := NewProgressBar(title, total, bytes)
+	g.add(bar)
+	return bar
+}
+
+// AddSpinner 添加转动图标
+func (g *ProgressGroup) AddSpinner(title string) *Spinner {
+	spinner := NewSpinner(title)
+	g.add(spinner)
+	return spinner
+}
+
+func (g *ProgressGroup) add(item progressItem) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.items = append(g.items, item)
+}
+
+// Start 开始定时刷新
+func (g *ProgressGroup) Start() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.running {
+		return
+	}
+	g.running = true
+	g.stop = make(chan struct{})
+	g.stopped = make(chan struct{})
+	g.lastPlain = time.Now()
+	g.region.Start()
+	go g.loop()
+}
+
+// SetExitOnSignal 见 LiveRegion.SetExitOnSignal
+func (g *ProgressGroup) SetExitOnSignal(exit bool) {
+	g.region.SetExitOnSignal(exit)
+}
+
+// Println 在进度上方输出日志
+func (g *ProgressGroup) Println(a ...any) {
+	g.region.Println(a...)
+}
+
+// Stop 停止刷新，输出最终的进度
+func (g *ProgressGroup) Stop() {
+	g.mu.Lock()
+	if !g.running {
+		g.mu.Unlock()
+		return
+	}
+	g.running = false
+	close(g.stop)
+	g.mu.Unlock()
+
+	<-g.stopped
+	g.render(true)
+	g.region.Close()
+}
+
+func (g *ProgressGroup) loop() {
+	defer close(g.stopped)
+
+	ticker := time.NewTicker(progressRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.render(false)
+		}
+	}
+}
+
+// render 输出所有进度。不是终端时按间隔输出，final 为true时总是输出
+func (g *ProgressGroup) render(final bool) {
+	g.mu.Lock()
+	items := append([]progressItem(nil), g.items...)
+	g.mu.Unlock()
+
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = item.String()
+	}
+
+	if !g.region.plain {
+		g.region.SetLines(lines)
+		return
+	}
+
+	g.mu.Lock()
+	due := final || time.Since(g.lastPlain) >= progressPlainInterval
+	if due {
+		g.lastPlain = time.Now()
+	}
+	g.mu.Unlock()
+	if due {
+		for _, line := range lines {
+			g.region.Println(line)
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrPromptInterrupted 提示被 Ctrl-C 中断
+var ErrPromptInterrupted = errors.New("prompt interrupted")
+
+// Prompter 终端交互提示：确认、输入、密码、单选、多选
+// 在Linux上且输入是终端时使用raw模式逐键读取（方向键选择、不回显密码）；否则按行读取，也适合脚本通过管道输入
+// 提示输出到 out，一般为 os.Stderr，不影响程序的正常输出
+type Prompter struct {
+	in     *os.File
+	out    io.Writer
+	reader *bufio.Reader // 只在raw模式下使用；按行读取时不缓冲，不多读属于程序其他部分的输入
+}
+
+// NewPrompter 创建交互提示
+func NewPrompter(in *os.File, out io.Writer) *Prompter {
+	return &Prompter{in: in, out: out}
+}
+
+var stdPrompter = NewPrompter(os.Stdin, os.Stderr)
+
+// Confirm 从标准输入确认，见 Prompter.Confirm
+func Confirm(label string, def bool) (bool, error) { return stdPrompter.Confirm(label, def) }
+
+// Input 从标准输入读取文本，见 Prompter.Input
+func Input(label string, def string) (string, error) { return stdPrompter.Input(label, def) }
+
+// Password 从标准输入读取密码，见 Prompter.Password
+func Password(label string) (string, error) { return stdPrompter.Password(label) }
+
+// Select 从标准输入单选，见 Prompter.Select
+func Select(label string, options []string, def int) (int, error) {
+	return stdPrompter.Select(label, options, def)
+}
+
+// MultiSelect 从标准输入多选，见 Prompter.MultiSelect
+func MultiSelect(label string, options []string, selected []int) ([]int, error) {
+	return stdPrompter.MultiSelect(label, options, selected)
+}
+
+// Confirm 是/否确认，直接回车时返回 def
+func (p *Prompter) Confirm(label string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	answer := def
+	if p.canRaw() {
+		err := p.runRaw(true, func() []string {
+			return []string{label + " " + promptHintStyle.RenderFor(p.out, hint) + " "}
+		}, func(key promptKey) (bool, error) {
+			switch {
+			case key.code == keyEnter:
+				return true, nil
+			case key.code == keyRune && strings.ContainsRune("yY", key.r):
+				answer = true
+				return true, nil
+			case key.code == keyRune && strings.ContainsRune("nN", key.r):
+				answer = false
+				return true, nil
+			}
+			return false, nil
+		}, func() string {
+			return label + " " + promptAnswerStyle.RenderFor(p.out, confirmText(answer))
+		})
+		return answer, err
+	}
+
+	for {
+		line, err := p.readLine(label + " " + hint + " ")
+		if err != nil {
+			return def, err
+		}
+		switch strings.ToLower(line) {
+		case "":
+			return def, nil
+		case "y", "yes", "是":
+			return true, nil
+		case "n", "no", "否":
+			return false, nil
+		}
+	}
+}
+
+// Input 读取一行文本，直接回车时返回 def
+func (p *Prompter) Input(label string, def string) (string, error) {
+	return p.input(label, def, false)
+}
+
+// Password 读取密码，输入不回显
+// 输入不是终端时按行读取，无法隐藏
+func (p *Prompter) Password(label string) (string, error) {
+	return p.input(label, "", true)
+}
+
+func (p *Prompter) input(label string, def string, secret bool) (string, error) {
+	if !p.canRaw() {
+		prompt := label + " "
+		if def != "" {
+			prompt += "[" + def + "] "
+		}
+		line, err := p.readLine(prompt)
+		if line == "" && err == nil {
+			line = def
+		}
+		return line, err
+	}
+
+	var value []string // 按字形簇保存，退格删除一个完整的字符
+	text := func() string {
+		if len(value) == 0 && !secret {
+			return def
+		}
+		return strings.Join(value, "")
+	}
+	err := p.runRaw(true, func() []string {
+		line := label + " "
+		switch {
+		case secret:
+		case len(value) == 0 && def != "":
+			line += promptHintStyle.RenderFor(p.out, def)
+		default:
+			line += strings.Join(value, "")
+		}
+		return []string{line}
+	}, func(key promptKey) (bool, error) {
+		switch key.code {
+		case keyEnter:
+			return true, nil
+		case keyBackspace:
+			if len(value) > 0 {
+				value = value[:len(value)-1]
+			}
+		case keyEOF:
+			if len(value) == 0 {
+				return true, io.EOF
+			}
+		case keyRune:
+			value = append(value, string(key.r))
+			// 组合字符等与前一个字符合并为一个字形簇
+			if n := len(value); n > 1 {
+				if g := graphemes(value[n-2] + value[n-1]); len(g) == 1 {
+					value = append(value[:n-2], g[0])
+				}
+			}
+		}
+		return false, nil
+	}, func() string {
+		if secret {
+			return label
+		}
+		return label + " " + promptAnswerStyle.RenderFor(p.out, text())
+	})
+	return text(), err
+}
+
+// Select 单选，返回选中的下标，def 为默认选中的下标
+// 终端中用 ↑↓ 或 k/j 移动，回车确认
+func (p *Prompter) Select(label string, options []string, def int) (int, error) {
+	if len(options) == 0 {
+		return -1, errors.New("select requires at least one option")
+	}
+	cur := min(max(def, 0), len(options)-1)
+	if !p.canRaw() {
+		p.printOptions(options, nil)
+		for {
+			line, err := p.readLine(fmt.Sprintf("%s [%d] ", label, cur+1))
+			if err != nil {
+				return cur, err
+			}
+			if line == "" {
+				return cur, nil
+			}
+			if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(options) {
+				return n - 1, nil
+			}
+		}
+	}
+
+	err := p.runRaw(false, func() []string {
+		lines := []string{label + " " + promptHintStyle.RenderFor(p.out, "(↑↓选择，回车确认)")}
+		for i, option := range options {
+			lines = append(lines, promptOptionLine(p.out, option, i == cur, ""))
+		}
+		return lines
+	}, func(key promptKey) (bool, error) {
+		switch {
+		case key.code == keyEnter:
+			return true, nil
+		case key.code == keyUp || key.code == keyRune && key.r == 'k':
+			cur = (cur + len(options) - 1) % len(options)
+		case key.code == keyDown || key.code == keyRune && key.r == 'j' || key.code == keyTab:
+			cur = (cur + 1) % len(options)
+		case key.code == keyHome:
+			cur = 0
+		case key.code == keyEnd:
+			cur = len(options) - 1
+		}
+		return false, nil
+	}, func() string {
+		return label + " " + promptAnswerStyle.RenderFor(p.out, options[cur])
+	}, func() int { return cur + 1 })
+	return cur, err
+}
+
+// MultiSelect 多选，返回按顺序排列的选中的下标，selected 为默认选中的下标
+// 终端中用 ↑↓ 移动，空格切换选中，a 全选/全不选，回车确认
+func (p *Prompter) MultiSelect(label string, options []string, selected []int) ([]int, error) {
+	if len(options) == 0 {
+		return []int{}, errors.New("multi-select requires at least one option")
+	}
+	checked := make([]bool, len(options))
+	for _, i := range selected {
+		if i >= 0 && i < len(options) {
+			checked[i] = true
+		}
+	}
+	result := func() []int {
+		indexes := make([]int, 0, len(options))
+		for i, ok := range checked {
+			if ok {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+
+	if !p.canRaw() {
+		p.printOptions(options, checked)
+		for {
+			line, err := p.readLine(label + " (序号，逗号分隔) ")
+			if err != nil || line == "" {
+				return result(), err
+			}
+			if indexes, ok := parsePromptIndexes(line, len(options)); ok {
+				return indexes, nil
+			}
+		}
+	}
+
+	cur := 0
+	err := p.runRaw(false, func() []string {
+		lines := []string{label + " " + promptHintStyle.RenderFor(p.out, "(↑↓移动，空格选择，a全选，回车确认)")}
+		for i, option := range options {
+			mark := "◯ "
+			if checked[i] {
+				mark = "◉ "
+			}
+			lines = append(lines, promptOptionLine(p.out, option, i == cur, mark))
+		}
+		return lines
+	}, func(key promptKey) (bool, error) {
+		switch {
+		case key.code == keyEnter:
+			return true, nil
+		case key.code == keyUp || key.code == keyRune && key.r == 'k':
+			cur = (cur + len(options) - 1) % len(options)
+		case key.code == keyDown || key.code == keyRune && key.r == 'j' || key.code == keyTab:
+			cur = (cur + 1) % len(options)
+		case key.code == keyHome:
+			cur = 0
+		case key.code == keyEnd:
+			cur = len(options) - 1
+		case key.code == keyRune && key.r == ' ':
+			checked[cur] = !checked[cur]
+		case key.code == keyRune && key.r == 'a':
+			all := len(result()) < len(options)
+			for i := range checked {
+				checked[i] = all
+			}
+		}
+		return false, nil
+	}, func() string {
+		names := make([]string, 0, len(options))
+		for _, i := range result() {
+			names = append(names, options[i])
+		}
+		return label + " " + promptAnswerStyle.RenderFor(p.out, strings.Join(names, ", "))
+	}, func() int { return cur + 1 })
+	return result(), err
+}
+
+var (
+	promptHintStyle   = NewStyle().Dim()
+	promptAnswerStyle = NewStyle().Fg(ColorCyan)
+	promptCursorStyle = NewStyle().Fg(ColorCyan).Bold()
+)
+
+func confirmText(yes bool) string {
+	if yes {
+		return "是"
+	}
+	return "否"
+}
+
+// promptOptionLine 选项的一行，当前行前面显示箭头
+func promptOptionLine(w io.Writer, option string, current bool, mark string) string {
+	if current {
+		return promptCursorStyle.RenderFor(w, "❯ "+mark+option)
+	}
+	return "  " + mark + option
+}
+
+// parsePromptIndexes 解析 "1,3 5" 形式的序号（从1开始），返回从0开始的下标
+func parsePromptIndexes(line string, n int) ([]int, bool) {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' '
+	})
+	seen := make([]bool, n)
+	for _, field := range fields {
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 1 || i > n {
+			return nil, false
+		}
+		seen[i-1] = true
+	}
+	indexes := make([]int, 0, len(fields))
+	for i, ok := range seen {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, true
+}
+
+// printOptions 按行读取时输出带序号的选项
+func (p *Prompter) printOptions(options []string, checked []bool) {
+	for i, option := range options {
+		mark := ""
+		if checked != nil && checked[i] {
+			mark = " *"
+		}
+		fmt.Fprintf(p.out, "%3d) %s%s\n", i+1, option, mark)
+	}
+}
+
+// readLine 输出提示后读取一行，去掉首尾空白
+func (p *Prompter) readLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	// 逐字节读到换行为止，之后的输入留给程序的其他部分（例如调用方的 bufio.Scanner）
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := p.in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimSpace(string(line)), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				err = nil
+			}
+			return strings.TrimSpace(string(line)), err
+		}
+	}
+}
+
+// canRaw 平台支持raw模式，且输入和输出都是终端时才逐键交互
+func (p *Prompter) canRaw() bool {
+	return rawModeSupported && IsTerminal(p.in) && isTerminalWriter(p.out)
+}
+
+// runRaw 在raw模式下循环：输出 render 的各行，读取按键交给 handle，直到 handle 返回 true 或出错
+// 结束后把提示替换为 summary 的一行；keepCursor 为 true 时光标停在最后一行的末尾（用于输入）
+// focus 可选，返回需要保持在可视区域内的行
+func (p *Prompter) runRaw(keepCursor bool, render func() []string, handle func(promptKey) (bool, error), summary func() string, focus ...func() int) error {
+	restore, err := makeRaw(p.in)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	// 转义序列需要判断是否还有缓冲的数据，只在raw模式下缓冲
+	p.reader = bufio.NewReader(p.in)
+	defer func() { p.reader = nil }()
+
+	diff := newPrintDiff(p.out)
+	diff.SetHighlightStyles(NewStyle(), NewStyle())
+	if !keepCursor {
+		fmt.Fprint(p.out, hideCursor)
+		defer fmt.Fprint(p.out, showCursor)
+	}
+
+	top := 0
+	for {
+		lines := render()
+		if len(focus) > 0 {
+			// 滚动使当前选项可见
+			line, rows := focus[0](), diff.viewportSize().Rows
+			if line < top {
+				top = line
+			} else if rows > 0 && line >= top+rows {
+				top = line - rows + 1
+			}
+			diff.ScrollTo(top)
+		}
+		diff.SetLines(lines)
+		if keepCursor {
+			fmt.Fprintf(p.out, "\r"+moveToCol, StrTerminalLen(lines[len(lines)-1])+1)
+		}
+
+		key, err := p.readKey()
+		if err == nil {
+			if key.code == keyInterrupt {
+				err = ErrPromptInterrupted
+			} else {
+				var done bool
+				if done, err = handle(key); err == nil && !done {
+					continue
+				}
+			}
+		}
+
+		if err != nil {
+			diff.SetLines([]string{StripANSI(lines[0])})
+		} else {
+			diff.SetLines([]string{summary()})
+		}
+		fmt.Fprint(p.out, "\n")
+		return err
+	}
+}
+
+// keyCode 按键的类型
+type keyCode int
+
+const (
+	keyRune keyCode = iota // 可打印字符，见 promptKey.r
+	keyEnter
+	keyBackspace
+	keyTab
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyEsc
+	keyInterrupt // Ctrl-C
+	keyEOF       // Ctrl-D
+	keyUnknown
+)
+
+type promptKey struct {
+	code keyCode
+	r    rune
+}
+
+// readKey 读取一个按键，解析方向键等转义序列
+func (p *Prompter) readKey() (promptKey, error) {
+	r, _, err := p.reader.ReadRune()
+	if err != nil {
+		return promptKey{}, err
+	}
+	switch r {
+	case '\r', '\n':
+		return promptKey{code: keyEnter}, nil
+	case '\t':
+		return promptKey{code: keyTab}, nil
+	case 3:
+		return promptKey{code: keyInterrupt}, nil
+	case 4:
+		return promptKey{code: keyEOF}, nil
+	case 8, 127:
+		return promptKey{code: keyBackspace}, nil
+	case 27:
+		return p.readEscape()
+	}
+	if r < 32 {
+		return promptKey{code: keyUnknown}, nil
+	}
+	return promptKey{code: keyRune, r: r}, nil
+}
+
+// readEscape 解析 ESC 之后的 CSI（ESC [）或 SS3（ESC O）序列
+// 终端一次写入整个序列，ESC 后面没有缓冲的数据时认为是单独按下了 Esc
+func (p *Prompter) readEscape() (promptKey, error) {
+	if p.reader.Buffered() == 0 {
+		return promptKey{code: keyEsc}, nil
+	}
+	b, err := p.reader.ReadByte()
+	if err != nil {
+		return promptKey{}, err
+	}
+	if b != '[' && b != 'O' {
+		return promptKey{code: keyUnknown}, nil
+	}
+	var params []byte
+	for {
+		c, err := p.reader.ReadByte()
+		if err != nil {
+			return promptKey{}, err
+		}
+		if c < 0x40 || c > 0x7e {
+			params = append(params, c)
+			continue
+		}
+		switch c {
+		case 'A':
+			return promptKey{code: keyUp}, nil
+		case 'B':
+			return promptKey{code: keyDown}, nil
+		case 'C':
+			return promptKey{code: keyRight}, nil
+		case 'D':
+			return promptKey{code: keyLeft}, nil
+		case 'H':
+			return promptKey{code: keyHome}, nil
+		case 'F':
+			return promptKey{code: keyEnd}, nil
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return promptKey{code: keyHome}, nil
+			case "4", "8":
+				return promptKey{code: keyEnd}, nil
+			}
+		}
+		return promptKey{code: keyUnknown}, nil
+	}
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package util
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const rawModeSupported = true
+
+// makeRaw 关闭行缓冲、回显和信号键（Ctrl-C 作为普通按键读取），保留输出处理使 \n 仍然换行
+// 返回恢复原来设置的函数
+func makeRaw(f *os.File) (func(), error) {
+	var old syscall.Termios
+	if err := ioctlFile(f, syscall.TCGETS, unsafe.Pointer(&old)); err != nil {
+		return nil, os.NewSyscallError("ioctl TCGETS", err)
+	}
+	raw := old
+	raw.Iflag &^= syscall.ICRNL | syscall.INLCR | syscall.IGNCR | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctlFile(f, syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		return nil, os.NewSyscallError("ioctl TCSETS", err)
+	}
+	return func() {
+		_ = ioctlFile(f, syscall.TCSETS, unsafe.Pointer(&old))
+	}, nil
+}
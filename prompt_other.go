@@ -0,0 +1,15 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+	"os"
+)
+
+// 不支持raw模式，Prompter 按行读取输入
+const rawModeSupported = false
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("terminal raw mode is not supported on this platform")
+}
@@ -0,0 +1,337 @@
+package util
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RotateOptions 文件切分的参数
+type RotateOptions struct {
+	MaxSize    int64 // 单个文件的最大字节数，<=0 表示不按大小切分
+	Daily      bool  // 按自然日切分，日期使用 time.Local（调用 InitTimezone 后为东八区）
+	MaxBackups int   // 保留的旧文件数量，<=0 表示全部保留
+	Compress   bool  // 切分出的旧文件用gzip压缩，在后台进行
+}
+
+// RotateWriter 可切分的文件写入器，并发安全
+// 当前文件名为 prefix-日期ext，例如 data-20250101.log
+// 按大小切分出的旧文件为 prefix-日期.序号ext，例如 data-20250101.1.log，压缩后再加 .gz
+type RotateWriter struct {
+	dir     string
+	prefix  string
+	ext     string
+	opts    RotateOptions
+	pattern *regexp.Regexp // 匹配本写入器产生的所有文件
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	date   int // 当前文件的日期，例如 20250101
+	closed bool
+
+	bgMu sync.Mutex     // 串行执行压缩和清理
+	bgWg sync.WaitGroup // Close 时等待压缩和清理结束
+}
+
+var _ io.WriteCloser = (*RotateWriter)(nil)
+
+// NewRotateWriter 创建切分写入器，dir 不存在时自动创建，当天的文件已存在时追加写入
+// 例子：NewRotateWriter("logs", "data", ".log", RotateOptions{Daily: true, MaxBackups: 7})
+func NewRotateWriter(dir string, prefix string, ext string, opts RotateOptions) (*RotateWriter, error) {
+	if err := EnsureDir(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &RotateWriter{
+		dir:     dir,
+		prefix:  prefix,
+		ext:     ext,
+		opts:    opts,
+		pattern: regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `-(\d{8})(?:\.(\d+))?` + regexp.QuoteMeta(ext) + `(?:\.gz)?$`),
+	}
+	if err := w.open(time.Now()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write 写入数据，写入前检查是否需要切分。单次写入的数据不会被拆到两个文件
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	if w.file == nil { // 上次切分失败
+		if err := w.open(now); err != nil {
+			return 0, err
+		}
+	}
+	switch {
+	case w.opts.Daily && DateTimeToIntDate(now.In(time.Local)) != w.date:
+		if err := w.rotateDaily(now); err != nil {
+			return 0, err
+		}
+	case w.opts.MaxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.opts.MaxSize:
+		if err := w.rotateSize(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即切分当前文件
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return w.open(time.Now())
+	}
+	if w.opts.Daily && DateTimeToIntDate(time.Now().In(time.Local)) != w.date {
+		return w.rotateDaily(time.Now())
+	}
+	return w.rotateSize(time.Now())
+}
+
+// Sync 把当前文件同步到磁盘
+func (w *RotateWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close 关闭当前文件，并等待后台的压缩和清理结束
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+	}
+	w.mu.Unlock()
+
+	w.bgWg.Wait()
+	return err
+}
+
+// 当前文件的路径
+func (w *RotateWriter) currentPath() string {
+	return filepath.Join(w.dir, w.prefix+"-"+strconv.Itoa(w.date)+w.ext)
+}
+
+// open 打开 now 当天的文件，失败时不改变当前的状态
+func (w *RotateWriter) open(now time.Time) error {
+	date := DateTimeToIntDate(now.In(time.Local))
+	path := filepath.Join(w.dir, w.prefix+"-"+strconv.Itoa(date)+w.ext)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	w.date = date
+	return nil
+}
+
+// 换日：旧文件的名字已经带着日期，不需要改名
+// 先打开新文件，成功后再关闭旧文件，失败时继续写旧文件，下次写入时重试
+func (w *RotateWriter) rotateDaily(now time.Time) error {
+	oldPath := w.currentPath()
+	old := w.file
+	if err := w.open(now); err != nil {
+		return err
+	}
+	_ = old.Close() // 数据已经写入，关闭失败也不影响新文件
+	w.afterRotate(oldPath)
+	return nil
+}
+
+// 按大小切分：当前文件改名为下一个序号
+// 改名前必须关闭文件（Windows不能改名打开的文件），之后任何一步失败时 file 为nil，下次写入时重新打开当前文件
+func (w *RotateWriter) rotateSize(now time.Time) error {
+	index, err := w.nextIndex()
+	if err != nil {
+		return err
+	}
+	oldPath := filepath.Join(w.dir, w.prefix+"-"+strconv.Itoa(w.date)+"."+strconv.Itoa(index)+w.ext)
+
+	err = w.file.Close()
+	w.file = nil
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(w.currentPath(), oldPath); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		// 当前文件已被外部删除，没有需要保留的旧文件，直接打开新文件
+		return w.open(now)
+	}
+	if err := w.open(now); err != nil {
+		return err
+	}
+	w.afterRotate(oldPath)
+	return nil
+}
+
+// 当天已切分的文件的最大序号 + 1
+func (w *RotateWriter) nextIndex() (int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return 0, err
+	}
+	date := strconv.Itoa(w.date)
+	maxIndex := 0
+	for _, entry := range entries {
+		match := w.pattern.FindStringSubmatch(entry.Name())
+		if match == nil || match[1] != date || match[2] == "" {
+			continue
+		}
+		if index, err := strconv.Atoi(match[2]); err == nil && index > maxIndex {
+			maxIndex = index
+		}
+	}
+	return maxIndex + 1, nil
+}
+
+// afterRotate 在后台压缩旧文件并清理多余的旧文件。调用方持有 mu
+func (w *RotateWriter) afterRotate(oldPath string) {
+	if !w.opts.Compress && w.opts.MaxBackups <= 0 {
+		return
+	}
+	current := w.currentPath()
+
+	w.bgWg.Add(1)
+	go func() {
+		defer w.bgWg.Done()
+		w.bgMu.Lock()
+		defer w.bgMu.Unlock()
+
+		if w.opts.Compress {
+			if err := gzipFile(oldPath); err != nil && !os.IsNotExist(err) { // 可能已被之前的清理删除
+				log.Printf("警告: 压缩切分的文件 %s 失败: %v", oldPath, err)
+			}
+		}
+		if err := w.removeOldFiles(current); err != nil {
+			log.Printf("警告: 清理切分的旧文件失败: %v", err)
+		}
+	}()
+}
+
+// removeOldFiles 按修改时间保留最新的 MaxBackups 个旧文件
+func (w *RotateWriter) removeOldFiles(current string) error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return err
+	}
+
+	type oldFile struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]oldFile, 0, len(entries))
+	for _, entry := range entries {
+		p := filepath.Join(w.dir, entry.Name())
+		if entry.IsDir() || p == current || !w.pattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, oldFile{path: p, modTime: info.ModTime()})
+	}
+	if len(files) <= w.opts.MaxBackups {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	var errs []error
+	for _, f := range files[w.opts.MaxBackups:] {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// gzipFile 把文件压缩为 file.gz 并删除原文件，保留原文件的修改时间以便按时间清理
+func gzipFile(file string) error {
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	gzFile := file + ".gz"
+	tmpName := gzFile + atomicTmpSuffix
+	out, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chtimes(tmpName, info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, gzFile); err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
@@ -1,13 +1,11 @@
 package util
 
-import "sort"
-
-// GroupSort 对切片进行分组排序处理，没必要排序的元素会保持原顺序
+// GroupSort 对切片进行分组排序处理，排序是稳定的，没必要排序的元素会保持原顺序
 // 参数:
 //
 //	src - 需要处理的源切片
-//	getGroupKey - 获取元素分组键的函数，返回字符串类型的分组标识
-//	sortGroup - 组间排序函数，参数是两个组的元素切片，返回true表示第一个组应排在第二个组前，为nil时不排序
+//	getGroupKey - 获取元素分组键的函数，键可以是任意可比较的类型
+//	sortGroup - 组间排序函数，参数是两个组的元素切片，返回true表示第一个组应排在第二个组前，为nil时按组第一次出现的顺序
 //	sortMember - 组内排序函数，用于对同一组的元素进行排序，返回true表示第一个元素应排在第二个元素前，为nil时不排序
 //
 // 返回值:
@@ -16,46 +14,18 @@ import "sort"
 //
 // 注意:
 //
-//	排序函数返回false都会触发元素更换位置，也就是大小比较用好等于号
+//	排序函数用 < 或 <= 比较都可以，相等的元素（组）保持原顺序
 //	sortGroup有2个slice传进来，它们的长度>=1
-func GroupSort[T any](src []T, getGroupKey func(T) string, sortGroup func([]T, []T) bool, sortMember func(T, T) bool) []T {
-	groupKeys := make([]string, 0, len(src))
-
-	// 分类成组
-	groupMap := make(map[string][]T, len(src))
-	for _, one := range src {
-		key := getGroupKey(one)
-		group, ok := groupMap[key]
-		if !ok {
-			groupKeys = append(groupKeys, key) // 记录下所有组的key，写在这里是为了去重
-			group = make([]T, 0, len(src))
-		}
-		group = append(group, one)
-		groupMap[key] = group
-	}
-
-	// 组内排序
-	if sortMember != nil {
-		for _, v := range groupMap {
-			sort.Slice(v, func(i, j int) bool {
-				return sortMember(v[i], v[j])
-			})
-		}
-	}
-
-	// 组间排序
+//	需要多级分组或分组结果时使用 GroupSortLevels
+func GroupSort[T any, K comparable](src []T, getGroupKey func(T) K, sortGroup func([]T, []T) bool, sortMember func(T, T) bool) []T {
+	level := GroupLevel[T, K]{Key: getGroupKey}
 	if sortGroup != nil {
-		sort.Slice(groupKeys, func(i, j int) bool {
-			return sortGroup(groupMap[groupKeys[i]], groupMap[groupKeys[j]])
-		})
-	}
-
-	// 返回结果
-	dest := make([]T, 0, len(src))
-	for _, v := range groupKeys {
-		dest = append(dest, groupMap[v]...)
+		less := stableLess(sortGroup)
+		level.Less = func(a, b Group[T, K]) bool {
+			return less(a.Members, b.Members)
+		}
 	}
-	return dest
+	return GroupMembers(GroupSortLevels(src, []GroupLevel[T, K]{level}, sortMember))
 }
 
 // GetReverseIndex 获取当前数组下标对应的元素在数据反转后的新下标
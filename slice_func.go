@@ -0,0 +1,192 @@
+package util
+
+// 切片的通用函数
+// 约定：输入为nil或空时返回空的非nil结果（map同理），不修改输入，结果不与输入共享底层数组（Window除外）
+// 集合运算（Uniq、Difference、Intersection、Union）保留元素第一次出现的顺序
+
+// Pair Zip 的结果
+type Pair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// Map 对每个元素调用 f，返回结果组成的切片
+func Map[T any, R any](src []T, f func(T) R) []R {
+	dest := make([]R, 0, len(src))
+	for _, one := range src {
+		dest = append(dest, f(one))
+	}
+	return dest
+}
+
+// Filter 返回 keep 为true的元素
+func Filter[T any](src []T, keep func(T) bool) []T {
+	dest := make([]T, 0, len(src))
+	for _, one := range src {
+		if keep(one) {
+			dest = append(dest, one)
+		}
+	}
+	return dest
+}
+
+// Reduce 从 init 开始依次用 f 累积每个元素，src为空时返回 init
+func Reduce[T any, A any](src []T, init A, f func(A, T) A) A {
+	acc := init
+	for _, one := range src {
+		acc = f(acc, one)
+	}
+	return acc
+}
+
+// Uniq 去重，保留第一次出现的元素
+func Uniq[T comparable](src []T) []T {
+	return UniqBy(src, func(one T) T { return one })
+}
+
+// UniqBy 按 key 去重，保留第一次出现的元素
+func UniqBy[T any, K comparable](src []T, key func(T) K) []T {
+	seen := make(map[K]struct{}, len(src))
+	dest := make([]T, 0, len(src))
+	for _, one := range src {
+		k := key(one)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		dest = append(dest, one)
+	}
+	return dest
+}
+
+// Partition 按 pred 把元素分为两部分，保持原顺序
+// 返回值:
+//
+//	matched - pred 为true的元素
+//	rest - pred 为false的元素
+func Partition[T any](src []T, pred func(T) bool) (matched []T, rest []T) {
+	matched = make([]T, 0, len(src))
+	rest = make([]T, 0, len(src))
+	for _, one := range src {
+		if pred(one) {
+			matched = append(matched, one)
+		} else {
+			rest = append(rest, one)
+		}
+	}
+	return matched, rest
+}
+
+// ChunkBy 把连续的元素分块，split(前一个元素, 当前元素) 为true时从当前元素开始新的一块
+// 例子：按相邻元素是否同一天分块 ChunkBy(records, func(a, b Record) bool { return !IsSameDate(a.Time, b.Time) })
+func ChunkBy[T any](src []T, split func(prev T, cur T) bool) [][]T {
+	chunks := make([][]T, 0, 4)
+	var chunk []T
+	for i, one := range src {
+		if i > 0 && split(src[i-1], one) {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+		chunk = append(chunk, one)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// Zip 把两个切片按下标配对，长度取较短的一个
+func Zip[A any, B any](a []A, b []B) []Pair[A, B] {
+	n := min(len(a), len(b))
+	dest := make([]Pair[A, B], 0, n)
+	for i := range n {
+		dest = append(dest, Pair[A, B]{First: a[i], Second: b[i]})
+	}
+	return dest
+}
+
+// Unzip Zip 的逆操作
+func Unzip[A any, B any](pairs []Pair[A, B]) ([]A, []B) {
+	a := make([]A, 0, len(pairs))
+	b := make([]B, 0, len(pairs))
+	for _, pair := range pairs {
+		a = append(a, pair.First)
+		b = append(b, pair.Second)
+	}
+	return a, b
+}
+
+// Flatten 把多个切片按顺序连接为一个
+func Flatten[T any](src [][]T) []T {
+	n := 0
+	for _, one := range src {
+		n += len(one)
+	}
+	dest := make([]T, 0, n)
+	for _, one := range src {
+		dest = append(dest, one...)
+	}
+	return dest
+}
+
+// KeyBy 以 key 为键建立索引，键相同时保留最后一个元素
+func KeyBy[T any, K comparable](src []T, key func(T) K) map[K]T {
+	dest := make(map[K]T, len(src))
+	for _, one := range src {
+		dest[key(one)] = one
+	}
+	return dest
+}
+
+// CountBy 统计每个 key 的元素个数
+func CountBy[T any, K comparable](src []T, key func(T) K) map[K]int {
+	dest := make(map[K]int)
+	for _, one := range src {
+		dest[key(one)]++
+	}
+	return dest
+}
+
+// Difference 在 a 中但不在 b 中的元素，去重
+func Difference[T comparable](a []T, b []T) []T {
+	exclude := toSet(b)
+	return Filter(Uniq(a), func(one T) bool {
+		_, ok := exclude[one]
+		return !ok
+	})
+}
+
+// Intersection 同时在 a 和 b 中的元素，去重，按 a 中的顺序
+func Intersection[T comparable](a []T, b []T) []T {
+	include := toSet(b)
+	return Filter(Uniq(a), func(one T) bool {
+		_, ok := include[one]
+		return ok
+	})
+}
+
+// Union 在任意一个切片中的元素，去重，按出现的顺序
+func Union[T comparable](src ...[]T) []T {
+	return Uniq(Flatten(src))
+}
+
+// Window 长度为 size 的滑动窗口，每次移动一个元素；size<=0 或超过切片长度时返回空
+// 窗口与 src 共享底层数组（容量已截断，对窗口 append 不会覆盖 src），需要修改时先复制
+func Window[T any](src []T, size int) [][]T {
+	if size <= 0 || size > len(src) {
+		return [][]T{}
+	}
+	windows := make([][]T, 0, len(src)-size+1)
+	for i := 0; i+size <= len(src); i++ {
+		windows = append(windows, src[i:i+size:i+size])
+	}
+	return windows
+}
+
+func toSet[T comparable](src []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(src))
+	for _, one := range src {
+		set[one] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,147 @@
+package util
+
+import "sort"
+
+// Number 可以求和的数字类型
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Group 分组的结果
+type Group[T any, K comparable] struct {
+	Key       K
+	Members   []T           // 组内的全部元素（包括所有下一级分组的元素），已排序
+	Subgroups []Group[T, K] // 下一级分组，最后一级为nil
+}
+
+// Count 组内元素的个数
+func (g Group[T, K]) Count() int {
+	return len(g.Members)
+}
+
+// GroupLevel 多级分组中的一级
+type GroupLevel[T any, K comparable] struct {
+	Key  func(T) K                   // 分组键
+	Less func(a, b Group[T, K]) bool // 同级组间排序（可以使用组的聚合值），为nil时按组第一次出现的顺序
+}
+
+// GroupSortLevels 多级分组排序：先按 levels[0] 分组，每组内再按 levels[1] 分组，依此类推
+// 排序都是稳定的，sortMember 对最后一级分组内的元素排序，用 < 或 <= 比较都可以，为nil时保持原顺序
+// 不同级的键类型不同时，可以统一转换为 string 或使用 any
+// 例子：按部门、再按职位分组，组内按工资排序
+//
+//	groups := GroupSortLevels(staffs, []GroupLevel[Staff, string]{
+//		{Key: func(s Staff) string { return s.Dept }},
+//		{Key: func(s Staff) string { return s.Title }},
+//	}, func(a, b Staff) bool { return a.Salary > b.Salary })
+//	sorted := GroupMembers(groups)
+func GroupSortLevels[T any, K comparable](src []T, levels []GroupLevel[T, K], sortMember func(T, T) bool) []Group[T, K] {
+	if len(levels) == 0 {
+		return []Group[T, K]{}
+	}
+	level := levels[0]
+
+	// 分类成组，记录组第一次出现的顺序
+	keys := make([]K, 0)
+	groupMap := make(map[K][]T)
+	for _, one := range src {
+		key := level.Key(one)
+		group, ok := groupMap[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+		groupMap[key] = append(group, one)
+	}
+
+	groups := make([]Group[T, K], 0, len(keys))
+	for _, key := range keys {
+		group := Group[T, K]{Key: key, Members: groupMap[key]}
+		if len(levels) > 1 {
+			// 组内的元素按下一级的分组顺序排列
+			group.Subgroups = GroupSortLevels(group.Members, levels[1:], sortMember)
+			group.Members = GroupMembers(group.Subgroups)
+		} else if sortMember != nil {
+			less := stableLess(sortMember)
+			sort.SliceStable(group.Members, func(i, j int) bool {
+				return less(group.Members[i], group.Members[j])
+			})
+		}
+		groups = append(groups, group)
+	}
+
+	if level.Less != nil {
+		sort.SliceStable(groups, func(i, j int) bool {
+			return level.Less(groups[i], groups[j])
+		})
+	}
+	return groups
+}
+
+// stableLess 把 <= 的比较函数转换为严格的小于，相等时返回false，使稳定排序保持相等元素的原顺序
+func stableLess[T any](less func(a, b T) bool) func(a, b T) bool {
+	return func(a, b T) bool {
+		return less(a, b) && !less(b, a)
+	}
+}
+
+// GroupMembers 按组的顺序连接所有组的元素
+func GroupMembers[T any, K comparable](groups []Group[T, K]) []T {
+	n := 0
+	for _, group := range groups {
+		n += len(group.Members)
+	}
+	dest := make([]T, 0, n)
+	for _, group := range groups {
+		dest = append(dest, group.Members...)
+	}
+	return dest
+}
+
+// SumBy 求和，用于分组的聚合，如 SumBy(group.Members, func(s Staff) float64 { return s.Salary })
+func SumBy[T any, N Number](src []T, value func(T) N) N {
+	var sum N
+	for _, one := range src {
+		sum += value(one)
+	}
+	return sum
+}
+
+// AvgBy 平均值，src为空时返回0
+func AvgBy[T any, N Number](src []T, value func(T) N) float64 {
+	if len(src) == 0 {
+		return 0
+	}
+	return float64(SumBy(src, value)) / float64(len(src))
+}
+
+// MinBy 返回 less 意义下最小的元素，相等时取第一个，src为空时返回false
+func MinBy[T any](src []T, less func(T, T) bool) (T, bool) {
+	var result T
+	if len(src) == 0 {
+		return result, false
+	}
+	result = src[0]
+	for _, one := range src[1:] {
+		if less(one, result) {
+			result = one
+		}
+	}
+	return result, true
+}
+
+// MaxBy 返回 less 意义下最大的元素，相等时取第一个，src为空时返回false
+func MaxBy[T any](src []T, less func(T, T) bool) (T, bool) {
+	var result T
+	if len(src) == 0 {
+		return result, false
+	}
+	result = src[0]
+	for _, one := range src[1:] {
+		if less(result, one) {
+			result = one
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,146 @@
+package util
+
+import "iter"
+
+// 切片函数的惰性版本，基于 iter.Seq，只在遍历时计算，可以提前 break
+// 切片可以用 slices.Values 转换为 iter.Seq，结果用 slices.Collect 收集为切片
+
+// MapSeq Map 的惰性版本
+func MapSeq[T any, R any](seq iter.Seq[T], f func(T) R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		for one := range seq {
+			if !yield(f(one)) {
+				return
+			}
+		}
+	}
+}
+
+// FilterSeq Filter 的惰性版本
+func FilterSeq[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for one := range seq {
+			if keep(one) && !yield(one) {
+				return
+			}
+		}
+	}
+}
+
+// ReduceSeq Reduce 的迭代器版本，会遍历完整个序列
+func ReduceSeq[T any, A any](seq iter.Seq[T], init A, f func(A, T) A) A {
+	acc := init
+	for one := range seq {
+		acc = f(acc, one)
+	}
+	return acc
+}
+
+// UniqSeq Uniq 的惰性版本
+func UniqSeq[T comparable](seq iter.Seq[T]) iter.Seq[T] {
+	return UniqBySeq(seq, func(one T) T { return one })
+}
+
+// UniqBySeq UniqBy 的惰性版本，每次遍历重新记录出现过的键
+func UniqBySeq[T any, K comparable](seq iter.Seq[T], key func(T) K) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		seen := make(map[K]struct{})
+		for one := range seq {
+			k := key(one)
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			if !yield(one) {
+				return
+			}
+		}
+	}
+}
+
+// ChunkBySeq ChunkBy 的惰性版本，每块是新的切片
+func ChunkBySeq[T any](seq iter.Seq[T], split func(prev T, cur T) bool) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		var chunk []T
+		for one := range seq {
+			if len(chunk) > 0 && split(chunk[len(chunk)-1], one) {
+				if !yield(chunk) {
+					return
+				}
+				chunk = nil
+			}
+			chunk = append(chunk, one)
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// ZipSeq Zip 的惰性版本，任意一个序列结束时结束
+func ZipSeq[A any, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		nextB, stop := iter.Pull(b)
+		defer stop()
+		for one := range a {
+			other, ok := nextB()
+			if !ok || !yield(one, other) {
+				return
+			}
+		}
+	}
+}
+
+// FlattenSeq Flatten 的惰性版本
+func FlattenSeq[T any](seq iter.Seq[[]T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for part := range seq {
+			for _, one := range part {
+				if !yield(one) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// WindowSeq Window 的惰性版本，每个窗口是新的切片；size<=0 时不产生窗口
+func WindowSeq[T any](seq iter.Seq[T], size int) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		if size <= 0 {
+			return
+		}
+		window := make([]T, 0, size)
+		for one := range seq {
+			if len(window) == size {
+				window = append(window[:0:0], window[1:]...)
+			}
+			window = append(window, one)
+			if len(window) == size && !yield(window) {
+				return
+			}
+		}
+	}
+}
+
+// ChunkSeq SplitSliceByMaxLength 的惰性版本，每块最多 maxLength 个元素，每块是新的切片
+func ChunkSeq[T any](seq iter.Seq[T], maxLength int) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		if maxLength <= 0 {
+			return
+		}
+		var chunk []T
+		for one := range seq {
+			chunk = append(chunk, one)
+			if len(chunk) == maxLength {
+				if !yield(chunk) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
@@ -3,19 +3,27 @@ package util
 import (
 	"strconv"
 	"strings"
-	"unicode/utf8"
 )
 
-// StrTerminalLen 获取字符串在终端中输出的长度
+// StrTerminalLen 获取字符串在终端中输出的长度，按字素簇计算，宽度规则见 RuneWidth
+// ANSI转义序列（颜色等）不占位置
 // 例子：
 // 中文 => 4
 // en   => 2
+// é    => 1（SetAmbiguousWidth(true) 时为2）
+// 👍🏻   => 2
 func StrTerminalLen(str string) int {
-	byteLen := len(str)
-	charLen := utf8.RuneCountInString(str)
-	chLen := (byteLen - charLen) / 2 // 1个中文 == 3个字节
-	enLen := charLen - chLen
-	return chLen*2 + enLen
+	total := 0
+	for str != "" {
+		if n := ansiSequenceLen(str); n > 0 {
+			str = str[n:]
+			continue
+		}
+		n, w := nextGrapheme(str)
+		total += w
+		str = str[n:]
+	}
+	return total
 }
 
 func Float2String(f float64, suffix string) string {
@@ -0,0 +1,399 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type colorMode uint8
+
+const (
+	colorNone  colorMode = iota
+	colorBasic           // 16色，value 为 0-15
+	color256             // 256色，value 为 0-255
+	colorRGB             // 真彩色，value 为 0xRRGGBB
+)
+
+// Color 终端颜色，零值表示不设置颜色
+type Color struct {
+	mode  colorMode
+	value uint32
+}
+
+// 16色，实际显示的颜色由终端的配色方案决定
+var (
+	ColorBlack        = Color{mode: colorBasic, value: 0}
+	ColorRed          = Color{mode: colorBasic, value: 1}
+	ColorGreen        = Color{mode: colorBasic, value: 2}
+	ColorYellow       = Color{mode: colorBasic, value: 3}
+	ColorBlue         = Color{mode: colorBasic, value: 4}
+	ColorPurple       = Color{mode: colorBasic, value: 5} // 即 magenta
+	ColorCyan         = Color{mode: colorBasic, value: 6}
+	ColorWhite        = Color{mode: colorBasic, value: 7}
+	ColorBrightBlack  = Color{mode: colorBasic, value: 8} // 灰色
+	ColorBrightRed    = Color{mode: colorBasic, value: 9}
+	ColorBrightGreen  = Color{mode: colorBasic, value: 10}
+	ColorBrightYellow = Color{mode: colorBasic, value: 11}
+	ColorBrightBlue   = Color{mode: colorBasic, value: 12}
+	ColorBrightPurple = Color{mode: colorBasic, value: 13}
+	ColorBrightCyan   = Color{mode: colorBasic, value: 14}
+	ColorBrightWhite  = Color{mode: colorBasic, value: 15}
+	ColorDefault      = Color{} // 不设置颜色，使用终端默认颜色
+)
+
+// Color256 256色调色板中的颜色
+func Color256(n uint8) Color {
+	return Color{mode: color256, value: uint32(n)}
+}
+
+// ColorRGB 真彩色
+func ColorRGB(r, g, b uint8) Color {
+	return Color{mode: colorRGB, value: uint32(r)<<16 | uint32(g)<<8 | uint32(b)}
+}
+
+// 生成颜色的SGR参数，background 为true时生成背景色
+// 前景色：30-37、90-97、38;5;n、38;2;r;g;b，背景色对应加10
+func (c Color) sgrParams(background bool) []string {
+	offset := 0
+	if background {
+		offset = 10
+	}
+	switch c.mode {
+	case colorBasic:
+		if c.value < 8 {
+			return []string{strconv.Itoa(30 + offset + int(c.value))}
+		}
+		return []string{strconv.Itoa(90 + offset + int(c.value) - 8)}
+	case color256:
+		return []string{strconv.Itoa(38 + offset), "5", strconv.Itoa(int(c.value))}
+	case colorRGB:
+		return []string{strconv.Itoa(38 + offset), "2",
+			strconv.Itoa(int(c.value >> 16 & 0xff)), strconv.Itoa(int(c.value >> 8 & 0xff)), strconv.Itoa(int(c.value & 0xff))}
+	}
+	return nil
+}
+
+// downgrade 把颜色转换为终端支持的颜色
+func (c Color) downgrade(depth ColorDepth) Color {
+	switch {
+	case depth == ColorDepthNone:
+		return Color{}
+	case c.mode == colorRGB && depth == ColorDepth256:
+		return Color{mode: color256, value: uint32(rgbTo256(c.rgb()))}
+	case c.mode == colorRGB && depth == ColorDepth16:
+		return Color{mode: colorBasic, value: uint32(rgbTo16(c.rgb()))}
+	case c.mode == color256 && depth == ColorDepth16:
+		if c.value < 16 {
+			return Color{mode: colorBasic, value: c.value}
+		}
+		return Color{mode: colorBasic, value: uint32(rgbTo16(xterm256ToRGB(uint8(c.value))))}
+	}
+	return c
+}
+
+func (c Color) rgb() (uint8, uint8, uint8) {
+	return uint8(c.value >> 16), uint8(c.value >> 8), uint8(c.value)
+}
+
+// rgbTo256 转换为256色中最接近的颜色：6x6x6的色块（16-231）或24级灰度（232-255）
+func rgbTo256(r, g, b uint8) uint8 {
+	if r == g && g == b {
+		switch {
+		case r < 8:
+			return 16
+		case r > 248:
+			return 231
+		default:
+			return uint8(232 + (int(r)-8)*24/241)
+		}
+	}
+	toCube := func(v uint8) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+	return uint8(16 + 36*toCube(r) + 6*toCube(g) + toCube(b))
+}
+
+// xterm256ToRGB 256色（>=16）对应的RGB值
+func xterm256ToRGB(n uint8) (uint8, uint8, uint8) {
+	if n >= 232 {
+		v := uint8(8 + 10*(int(n)-232))
+		return v, v, v
+	}
+	n -= 16
+	level := func(i uint8) uint8 {
+		if i == 0 {
+			return 0
+		}
+		return 55 + 40*i
+	}
+	return level(n / 36), level(n / 6 % 6), level(n % 6)
+}
+
+// rgbTo16 按各分量是否过半转换为8种基本色，整体较亮时使用高亮版本
+func rgbTo16(r, g, b uint8) uint8 {
+	var idx uint8
+	if r > 127 {
+		idx |= 1
+	}
+	if g > 127 {
+		idx |= 2
+	}
+	if b > 127 {
+		idx |= 4
+	}
+	bright := max(r, g, b) > 191
+	if idx == 0 { // 灰色使用高亮黑
+		bright = max(r, g, b) > 63
+	}
+	if bright {
+		idx += 8
+	}
+	return idx
+}
+
+type styleAttr uint8
+
+const (
+	attrBold styleAttr = 1 << iota
+	attrDim
+	attrItalic
+	attrUnderline
+	attrBlink
+	attrReverse
+	attrStrikethrough
+)
+
+// 属性的SGR参数，顺序与 styleAttr 一致
+var styleAttrSGR = []string{"1", "2", "3", "4", "5", "7", "9"}
+
+// Style 文本样式，方法都返回新的 Style，可以链式调用
+// 例子：NewStyle().Fg(ColorRed).Bold().Render("错误")
+type Style struct {
+	fg    Color
+	bg    Color
+	attrs styleAttr
+	link  string
+}
+
+// NewStyle 创建没有任何效果的样式
+func NewStyle() Style {
+	return Style{}
+}
+
+// Fg 前景色（文字颜色）
+func (s Style) Fg(c Color) Style {
+	s.fg = c
+	return s
+}
+
+// Bg 背景色
+func (s Style) Bg(c Color) Style {
+	s.bg = c
+	return s
+}
+
+// Bold 粗体
+func (s Style) Bold() Style { return s.with(attrBold) }
+
+// Dim 暗淡
+func (s Style) Dim() Style { return s.with(attrDim) }
+
+// Italic 斜体
+func (s Style) Italic() Style { return s.with(attrItalic) }
+
+// Underline 下划线
+func (s Style) Underline() Style { return s.with(attrUnderline) }
+
+// Blink 闪烁
+func (s Style) Blink() Style { return s.with(attrBlink) }
+
+// Reverse 前景色和背景色互换
+func (s Style) Reverse() Style { return s.with(attrReverse) }
+
+// Strikethrough 删除线
+func (s Style) Strikethrough() Style { return s.with(attrStrikethrough) }
+
+// Link 超链接（OSC 8），不支持的终端只显示文字
+func (s Style) Link(url string) Style {
+	s.link = url
+	return s
+}
+
+func (s Style) with(attr styleAttr) Style {
+	s.attrs |= attr
+	return s
+}
+
+// IsZero 是否没有任何效果
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+// Sequence 样式对应的SGR转义序列，没有颜色和属性时返回空字符串
+// 颜色按终端支持的颜色数量降级，见 DetectColorDepth
+func (s Style) Sequence() string {
+	return s.sequence(styleColorDepth())
+}
+
+func (s Style) sequence(depth ColorDepth) string {
+	s.fg, s.bg = s.fg.downgrade(depth), s.bg.downgrade(depth)
+	params := make([]string, 0, 8)
+	for i, sgr := range styleAttrSGR {
+		if s.attrs&(1<<i) != 0 {
+			params = append(params, sgr)
+		}
+	}
+	params = append(params, s.fg.sgrParams(false)...)
+	params = append(params, s.bg.sgrParams(true)...)
+	if len(params) == 0 {
+		return ""
+	}
+	return "\033[" + strings.Join(params, ";") + "m"
+}
+
+// Render 给文本加上样式，用于输出到标准输出，是否生效由 StyleEnabled 决定
+// 支持嵌套：text 中其他样式结束（重置）后恢复本样式
+// 输出到其他位置时使用 RenderFor
+func (s Style) Render(text string) string {
+	return s.RenderFor(os.Stdout, text)
+}
+
+// RenderFor 给将要输出到 w 的文本加上样式，是否生效由 StyleEnabledFor(w) 决定
+func (s Style) RenderFor(w io.Writer, text string) string {
+	if !StyleEnabledFor(w) {
+		return text
+	}
+	return s.render(text)
+}
+
+// Sprint 同 fmt.Sprint，结果加上样式
+func (s Style) Sprint(a ...any) string {
+	return s.Render(fmt.Sprint(a...))
+}
+
+// Sprintf 同 fmt.Sprintf，结果加上样式
+func (s Style) Sprintf(format string, a ...any) string {
+	return s.Render(fmt.Sprintf(format, a...))
+}
+
+// Fprint 输出到 w，是否加样式由 StyleEnabledFor(w) 决定
+func (s Style) Fprint(w io.Writer, a ...any) (int, error) {
+	return io.WriteString(w, s.RenderFor(w, fmt.Sprint(a...)))
+}
+
+// Fprintf 格式化后输出到 w，是否加样式由 StyleEnabledFor(w) 决定
+func (s Style) Fprintf(w io.Writer, format string, a ...any) (int, error) {
+	return io.WriteString(w, s.RenderFor(w, fmt.Sprintf(format, a...)))
+}
+
+func (s Style) render(text string) string {
+	seq := s.Sequence()
+	if seq != "" {
+		// 嵌套的样式结束时会重置所有样式，在重置后面重新打开本样式
+		text = strings.ReplaceAll(text, "\033[0m", "\033[0m"+seq)
+		text = strings.ReplaceAll(text, "\033[m", "\033[m"+seq)
+		text = seq + text + reset
+	}
+	if s.link != "" {
+		text = "\033]8;;" + s.link + "\033\\" + text + osc8Close
+	}
+	return text
+}
+
+// 样式开关：0 自动判断，1 强制开启，2 强制关闭
+var styleSwitch atomic.Int32
+
+const (
+	styleAuto int32 = iota
+	styleOn
+	styleOff
+)
+
+// SetStyleEnabled 强制开启或关闭样式，优先于自动判断
+func SetStyleEnabled(enabled bool) {
+	if enabled {
+		styleSwitch.Store(styleOn)
+	} else {
+		styleSwitch.Store(styleOff)
+	}
+}
+
+// ResetStyleEnabled 恢复为自动判断，并重新检测颜色数量（例如修改了 NO_COLOR、TERM 等环境变量后）
+func ResetStyleEnabled() {
+	styleSwitch.Store(styleAuto)
+	styleDepth.Store(styleDepthUnknown)
+}
+
+// 检测到的颜色数量，每次输出样式都检测环境变量太慢，第一次使用时检测后缓存
+var styleDepth atomic.Int32
+
+const styleDepthUnknown = -1
+
+func init() {
+	styleDepth.Store(styleDepthUnknown)
+}
+
+// cachedColorDepth 缓存的 DetectColorDepth 的结果
+func cachedColorDepth() ColorDepth {
+	if depth := styleDepth.Load(); depth != styleDepthUnknown {
+		return ColorDepth(depth)
+	}
+	depth := DetectColorDepth()
+	styleDepth.Store(int32(depth))
+	return depth
+}
+
+// 标准输出和标准错误是否为终端，程序运行期间不会变化，只检测一次
+var (
+	stdoutTerminal = sync.OnceValue(func() bool { return IsTerminal(os.Stdout) })
+	stderrTerminal = sync.OnceValue(func() bool { return IsTerminal(os.Stderr) })
+)
+
+// styleTerminal w 是否为终端，标准输出和标准错误使用缓存的结果
+func styleTerminal(w io.Writer) bool {
+	switch w {
+	case os.Stdout:
+		return stdoutTerminal()
+	case os.Stderr:
+		return stderrTerminal()
+	}
+	return isTerminalWriter(w)
+}
+
+// StyleEnabled 输出到标准输出时是否使用样式，见 StyleEnabledFor
+func StyleEnabled() bool {
+	return StyleEnabledFor(os.Stdout)
+}
+
+// StyleEnabledFor 输出到 w 时是否使用样式
+// 优先使用 SetStyleEnabled 的设置；否则 w 不是终端，或 DetectColorDepth 判断不支持颜色（包括设置了 NO_COLOR）时关闭
+// 颜色数量在第一次使用时检测并缓存，见 ResetStyleEnabled
+func StyleEnabledFor(w io.Writer) bool {
+	switch styleSwitch.Load() {
+	case styleOn:
+		return true
+	case styleOff:
+		return false
+	}
+	return cachedColorDepth() != ColorDepthNone && styleTerminal(w)
+}
+
+// styleColorDepth 生成样式时使用的颜色数量，强制开启样式时不降级
+func styleColorDepth() ColorDepth {
+	depth := cachedColorDepth()
+	if depth == ColorDepthNone {
+		return ColorDepthTrueColor
+	}
+	return depth
+}
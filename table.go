@@ -1,51 +1,398 @@
 package util
 
 import (
-	"bytes"
+	"strconv"
 	"strings"
 )
 
 // TableStr 获取可以展示表格的字符串
 // 将多个line[]string，加入lines[][]string中
 // 当 colMaxWidth 为nil，花费时间来自动计算
+// 需要表头、边框、对齐等效果时使用 NewTable
 func TableStr(lines [][]string, colMaxWidth []int) string {
-	// 获取列最小长度
-	if colMaxWidth == nil {
-		colMaxWidth = make([]int, 0, 16)
-		for _, line := range lines {
-			// 保证 colMaxWidth 数组空间足够
-			if len(line) > len(colMaxWidth) {
-				extended := make([]int, len(line))
-				copy(extended, colMaxWidth)
-				colMaxWidth = extended
+	return NewTable().AddRows(lines).SetColWidths(colMaxWidth).legacyString()
+}
+
+// Align 单元格的对齐方式
+type Align int
+
+const (
+	AlignAuto   Align = iota // 整列都是数字时右对齐，否则左对齐
+	AlignLeft                // 左对齐
+	AlignRight               // 右对齐
+	AlignCenter              // 居中
+)
+
+// CellOverflow 单元格超过列宽时的处理方式
+type CellOverflow int
+
+const (
+	CellTruncate     CellOverflow = iota // 截断，结尾显示省略号
+	CellWrap                             // 按单词折行，单元格变为多行
+	CellOverflowNone                     // 不处理，原样输出（会破坏后面的列的对齐）
+)
+
+// 表格的横线，fill 为空表示没有这条线
+type tableLine struct {
+	left, fill, cross, right string
+}
+
+// TableBorder 表格的边框样式
+type TableBorder struct {
+	top, header, bottom tableLine // 顶部、表头与内容之间（也用于内容与表尾之间）、底部的横线
+	left, sep, right    string    // 左边、列之间、右边的竖线
+	pad                 int       // 单元格左右两边的空格数
+	markdown            bool      // 表头下的横线按Markdown的语法标记对齐方式
+}
+
+var (
+	// TableBorderNone 没有边框，列之间用两个空格分隔
+	TableBorderNone = TableBorder{sep: "  "}
+	// TableBorderASCII 只用ASCII字符的边框，兼容所有终端
+	TableBorderASCII = TableBorder{
+		top:    tableLine{"+", "-", "+", "+"},
+		header: tableLine{"+", "-", "+", "+"},
+		bottom: tableLine{"+", "-", "+", "+"},
+		left:   "|", sep: "|", right: "|", pad: 1,
+	}
+	// TableBorderBox 制表符边框
+	TableBorderBox = TableBorder{
+		top:    tableLine{"┌", "─", "┬", "┐"},
+		header: tableLine{"├", "─", "┼", "┤"},
+		bottom: tableLine{"└", "─", "┴", "┘"},
+		left:   "│", sep: "│", right: "│", pad: 1,
+	}
+	// TableBorderMarkdown GitHub风格的Markdown表格
+	TableBorderMarkdown = TableBorder{
+		header: tableLine{"|", "-", "|", "|"},
+		left:   "|", sep: "|", right: "|", pad: 1,
+		markdown: true,
+	}
+)
+
+// Table 表格，设置方法都返回自身，可以链式调用
+// 例子：NewTable().SetHeader("名称", "数量").AddRow("苹果", "3").SetBorder(TableBorderBox).String()
+type Table struct {
+	header    []string
+	rows      [][]string
+	footer    [][]string
+	aligns    []Align
+	maxWidths []int // 每列的最大宽度，<=0 表示不限制
+	colWidths []int // 固定的列宽，为nil时自动计算
+	overflow  CellOverflow
+	border    TableBorder
+}
+
+// NewTable 创建表格，默认没有边框，超过最大宽度的单元格截断
+func NewTable() *Table {
+	return &Table{border: TableBorderNone}
+}
+
+// SetHeader 设置表头
+func (t *Table) SetHeader(cols ...string) *Table {
+	t.header = cols
+	return t
+}
+
+// AddRow 添加一行
+func (t *Table) AddRow(cols ...string) *Table {
+	t.rows = append(t.rows, cols)
+	return t
+}
+
+// AddRows 添加多行
+func (t *Table) AddRows(rows [][]string) *Table {
+	t.rows = append(t.rows, rows...)
+	return t
+}
+
+// AddFooter 添加表尾（合计等汇总行），与内容之间有分隔线
+func (t *Table) AddFooter(cols ...string) *Table {
+	t.footer = append(t.footer, cols)
+	return t
+}
+
+// SetAlign 设置第 col 列的对齐方式（从0开始）
+func (t *Table) SetAlign(col int, align Align) *Table {
+	t.aligns = setTableColumn(t.aligns, col, align)
+	return t
+}
+
+// SetAligns 按顺序设置各列的对齐方式
+func (t *Table) SetAligns(aligns ...Align) *Table {
+	t.aligns = aligns
+	return t
+}
+
+// SetMaxWidth 设置第 col 列的最大宽度，超过时按 SetCellOverflow 处理
+func (t *Table) SetMaxWidth(col int, width int) *Table {
+	t.maxWidths = setTableColumn(t.maxWidths, col, width)
+	return t
+}
+
+// SetColWidths 设置固定的列宽，为nil时根据内容自动计算
+func (t *Table) SetColWidths(widths []int) *Table {
+	t.colWidths = widths
+	return t
+}
+
+// SetCellOverflow 设置单元格超过列宽时的处理方式，默认截断
+func (t *Table) SetCellOverflow(overflow CellOverflow) *Table {
+	t.overflow = overflow
+	return t
+}
+
+// SetBorder 设置边框样式，默认 TableBorderNone
+func (t *Table) SetBorder(border TableBorder) *Table {
+	t.border = border
+	return t
+}
+
+// String 输出表格，每行以换行符结尾
+func (t *Table) String() string {
+	lines := t.Lines()
+	var b strings.Builder
+	b.Grow(len(lines) * 100)
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Lines 输出表格的各行，不含换行符
+func (t *Table) Lines() []string {
+	n := t.colCount()
+	widths := t.widths(n)
+	aligns := t.resolveAligns(n)
+
+	lines := make([]string, 0, len(t.rows)+len(t.footer)+4)
+	lines = t.appendLine(lines, t.border.top, widths, aligns)
+	if t.header != nil {
+		lines = t.appendRow(lines, t.header, widths, aligns)
+		lines = t.appendLine(lines, t.border.header, widths, aligns)
+	}
+	for _, row := range t.rows {
+		lines = t.appendRow(lines, row, widths, aligns)
+	}
+	if len(t.footer) > 0 {
+		if !t.border.markdown { // Markdown没有表尾，直接作为普通的行
+			lines = t.appendLine(lines, t.border.header, widths, aligns)
+		}
+		for _, row := range t.footer {
+			lines = t.appendRow(lines, row, widths, aligns)
+		}
+	}
+	return t.appendLine(lines, t.border.bottom, widths, aligns)
+}
+
+// legacyString TableStr 原有的格式：只输出每行实际有的单元格，不处理表头、表尾和边框
+// 单元格补空格到列宽后再加两个空格的间隔，超过列宽的单元格原样输出，不加间隔
+func (t *Table) legacyString() string {
+	widths := t.colWidths
+	if widths == nil {
+		widths = make([]int, t.colCount())
+		for _, row := range t.rows {
+			for k, col := range row {
+				widths[k] = max(widths[k], StrTerminalLen(col))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(t.rows) * 100)
+	for _, row := range t.rows {
+		for k, col := range row {
+			width := 0
+			if k < len(widths) {
+				width = widths[k]
 			}
-			// 从当前行获取各个列的长度最大值
-			for k, col := range line {
-				realLen := StrTerminalLen(col)
-				if realLen > colMaxWidth[k] {
-					colMaxWidth[k] = realLen
-				}
+			b.WriteString(col)
+			if realLen := StrTerminalLen(col); realLen <= width {
+				b.WriteString(strings.Repeat(" ", width-realLen+2))
 			}
 		}
+		b.WriteString("\n")
 	}
+	return b.String()
+}
 
-	// 生成表格
-	colInterval := 2    // 列间隔的空格数
-	colDecollator := "" // 列之间的分割符号
-	var buffer bytes.Buffer
-	buffer.Grow(len(lines) * 100)
-	for _, line := range lines {
-		for k, col := range line {
-			var newCol string
-			realLen := StrTerminalLen(col)
-			if realLen > colMaxWidth[k] {
-				newCol = col + colDecollator
-			} else {
-				newCol = col + strings.Repeat(" ", colMaxWidth[k]-realLen+colInterval) + colDecollator
+func (t *Table) colCount() int {
+	n := len(t.header)
+	for _, row := range t.rows {
+		n = max(n, len(row))
+	}
+	for _, row := range t.footer {
+		n = max(n, len(row))
+	}
+	return n
+}
+
+// widths 各列的宽度：固定列宽，或内容的最大宽度（不超过最大宽度）
+func (t *Table) widths(n int) []int {
+	widths := make([]int, n)
+	if t.colWidths != nil {
+		copy(widths, t.colWidths)
+		return widths
+	}
+	measure := func(row []string) {
+		for k, col := range row {
+			for _, line := range strings.Split(col, "\n") {
+				widths[k] = max(widths[k], StrTerminalLen(line))
+			}
+		}
+	}
+	measure(t.header)
+	for _, row := range t.rows {
+		measure(row)
+	}
+	for _, row := range t.footer {
+		measure(row)
+	}
+	for k := range widths {
+		if k < len(t.maxWidths) && t.maxWidths[k] > 0 {
+			widths[k] = min(widths[k], t.maxWidths[k])
+		}
+		if t.border.markdown { // Markdown的分隔线至少需要3个字符
+			widths[k] = max(widths[k], 3)
+		}
+	}
+	return widths
+}
+
+// resolveAligns 确定各列的对齐方式，AlignAuto 根据内容判断
+func (t *Table) resolveAligns(n int) []Align {
+	aligns := make([]Align, n)
+	for k := range aligns {
+		if k < len(t.aligns) && t.aligns[k] != AlignAuto {
+			aligns[k] = t.aligns[k]
+			continue
+		}
+		aligns[k] = AlignLeft
+		if tableColumnIsNumeric(t.rows, k) {
+			aligns[k] = AlignRight
+		}
+	}
+	return aligns
+}
+
+// tableColumnIsNumeric 除了空单元格外，整列都是数字（允许千分位逗号和百分号）
+func tableColumnIsNumeric(rows [][]string, k int) bool {
+	found := false
+	for _, row := range rows {
+		if k >= len(row) {
+			continue
+		}
+		cell := strings.TrimSpace(StripANSI(row[k]))
+		if cell == "" {
+			continue
+		}
+		cell = strings.TrimSuffix(strings.ReplaceAll(cell, ",", ""), "%")
+		if _, err := strconv.ParseFloat(cell, 64); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// appendLine 添加横线
+func (t *Table) appendLine(lines []string, line tableLine, widths []int, aligns []Align) []string {
+	if line.fill == "" {
+		return lines
+	}
+	var b strings.Builder
+	b.WriteString(line.left)
+	for k, w := range widths {
+		if k > 0 {
+			b.WriteString(line.cross)
+		}
+		if t.border.markdown {
+			b.WriteString(markdownAlignLine(w+2*t.border.pad, aligns[k]))
+			continue
+		}
+		b.WriteString(strings.Repeat(line.fill, w+2*t.border.pad))
+	}
+	b.WriteString(line.right)
+	return append(lines, b.String())
+}
+
+// markdownAlignLine 表头下的横线，用冒号标记对齐方式
+func markdownAlignLine(width int, align Align) string {
+	switch align {
+	case AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	case AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	default:
+		return strings.Repeat("-", width)
+	}
+}
+
+// appendRow 添加一行内容，单元格折行时占多行
+func (t *Table) appendRow(lines []string, row []string, widths []int, aligns []Align) []string {
+	cells := make([][]string, len(widths))
+	height := 1
+	for k := range widths {
+		col := ""
+		if k < len(row) {
+			col = row[k]
+		}
+		cells[k] = t.cellLines(col, widths[k])
+		height = max(height, len(cells[k]))
+	}
+
+	pad := strings.Repeat(" ", t.border.pad)
+	for i := range height {
+		var b strings.Builder
+		b.WriteString(t.border.left)
+		for k, w := range widths {
+			if k > 0 {
+				b.WriteString(t.border.sep)
 			}
-			buffer.WriteString(newCol)
+			text := ""
+			if i < len(cells[k]) {
+				text = cells[k][i]
+			}
+			b.WriteString(pad)
+			b.WriteString(StrAlign(text, w, aligns[k]))
+			b.WriteString(pad)
 		}
-		buffer.WriteString("\n")
+		b.WriteString(t.border.right)
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// cellLines 单元格按列宽处理后的各行
+func (t *Table) cellLines(col string, width int) []string {
+	lines := strings.Split(col, "\n")
+	if t.overflow == CellOverflowNone {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case StrTerminalLen(line) <= width:
+			result = append(result, line)
+		case t.overflow == CellWrap:
+			result = append(result, StrWordWrap(line, width)...)
+		default:
+			result = append(result, StrTruncate(line, width, "…"))
+		}
+	}
+	return result
+}
+
+// setTableColumn 设置第 col 列的值，长度不够时扩展
+func setTableColumn[T any](values []T, col int, value T) []T {
+	if col < 0 {
+		return values
+	}
+	for len(values) <= col {
+		var zero T
+		values = append(values, zero)
 	}
-	return buffer.String()
+	values[col] = value
+	return values
 }
@@ -0,0 +1,253 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TableFormat 表格的输出格式
+type TableFormat int
+
+const (
+	TableText     TableFormat = iota // 对齐的文本，使用设置的边框
+	TableMarkdown                    // GitHub风格的Markdown
+	TableHTML                        // HTML <table>
+	TableCSV                         // CSV
+	TableTSV                         // 制表符分隔
+	TableJSON                        // JSON 对象数组，键为表头
+)
+
+// Render 按格式输出表格，同一份表格可以用于终端、邮件和文件
+// 除了 TableText 外，单元格中的ANSI转义序列都会被去掉
+func (t *Table) Render(format TableFormat) (string, error) {
+	switch format {
+	case TableText:
+		return t.String(), nil
+	case TableMarkdown:
+		return t.Markdown(), nil
+	case TableHTML:
+		return t.HTML(), nil
+	case TableCSV:
+		return t.CSV(), nil
+	case TableTSV:
+		return t.TSV(), nil
+	case TableJSON:
+		b, err := t.JSON()
+		return string(b), err
+	default:
+		return "", fmt.Errorf("unknown table format %d", format)
+	}
+}
+
+// Data 表头、内容和表尾的纯文本数据（去掉ANSI转义序列）
+func (t *Table) Data() [][]string {
+	data := make([][]string, 0, len(t.rows)+len(t.footer)+1)
+	if t.header != nil {
+		data = append(data, stripANSIRow(t.header))
+	}
+	for _, row := range t.rows {
+		data = append(data, stripANSIRow(row))
+	}
+	for _, row := range t.footer {
+		data = append(data, stripANSIRow(row))
+	}
+	return data
+}
+
+// Markdown GitHub风格的Markdown表格，单元格不截断
+// 竖线会被转义，换行转换为 <br>
+// 没有表头时用空的表头，因为Markdown表格必须有表头
+func (t *Table) Markdown() string {
+	escape := func(row []string) []string {
+		cells := stripANSIRow(row)
+		for k, cell := range cells {
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			cells[k] = strings.ReplaceAll(cell, "\n", "<br>")
+		}
+		return cells
+	}
+
+	md := &Table{
+		header:   make([]string, t.colCount()),
+		aligns:   t.resolveAligns(t.colCount()),
+		overflow: CellOverflowNone,
+		border:   TableBorderMarkdown,
+	}
+	copy(md.header, escape(t.header))
+	for _, row := range t.rows {
+		md.rows = append(md.rows, escape(row))
+	}
+	for _, row := range t.footer {
+		md.footer = append(md.footer, escape(row))
+	}
+	return md.String()
+}
+
+// HTML <table> 表格，表头、内容、表尾分别在 <thead>、<tbody>、<tfoot> 中
+func (t *Table) HTML() string {
+	n := t.colCount()
+	aligns := t.resolveAligns(n)
+
+	var b strings.Builder
+	writeRow := func(row []string, tag string) {
+		b.WriteString("    <tr>")
+		for k := range n {
+			cell := ""
+			if k < len(row) {
+				cell = StripANSI(row[k])
+			}
+			b.WriteString("<" + tag)
+			switch aligns[k] {
+			case AlignRight:
+				b.WriteString(` style="text-align:right"`)
+			case AlignCenter:
+				b.WriteString(` style="text-align:center"`)
+			}
+			b.WriteString(">")
+			b.WriteString(strings.ReplaceAll(html.EscapeString(cell), "\n", "<br>"))
+			b.WriteString("</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+	writeSection := func(rows [][]string, section, tag string) {
+		if len(rows) == 0 {
+			return
+		}
+		b.WriteString("  <" + section + ">\n")
+		for _, row := range rows {
+			writeRow(row, tag)
+		}
+		b.WriteString("  </" + section + ">\n")
+	}
+
+	b.WriteString("<table>\n")
+	if t.header != nil {
+		writeSection([][]string{t.header}, "thead", "th")
+	}
+	writeSection(t.rows, "tbody", "td")
+	writeSection(t.footer, "tfoot", "td")
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// WriteCSV 以CSV格式写入 w，包括表头和表尾
+func (t *Table) WriteCSV(w io.Writer) error {
+	return t.writeDelimited(w, ',')
+}
+
+// WriteTSV 以制表符分隔的格式写入 w，包括表头和表尾
+func (t *Table) WriteTSV(w io.Writer) error {
+	return t.writeDelimited(w, '\t')
+}
+
+// CSV 以CSV格式输出
+func (t *Table) CSV() string {
+	var b strings.Builder
+	_ = t.WriteCSV(&b) // 写入 strings.Builder 不会失败
+	return b.String()
+}
+
+// TSV 以制表符分隔的格式输出
+func (t *Table) TSV() string {
+	var b strings.Builder
+	_ = t.WriteTSV(&b)
+	return b.String()
+}
+
+// SaveToCsv 保存为CSV文件，同 SaveToCsv
+func (t *Table) SaveToCsv(csvFile string, readOnly bool) error {
+	return SaveToCsv(t.Data(), csvFile, readOnly)
+}
+
+func (t *Table) writeDelimited(w io.Writer, comma rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if err := writer.WriteAll(t.Data()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// JSON 对象数组，每行一个对象，键为表头，按列的顺序输出
+// 表头缺少或为空的列用 "列号"（从1开始）作为键，重复的表头加上 "_列号" 使键唯一；没有表头时输出字符串数组的数组
+// 表尾是汇总数据，不输出
+func (t *Table) JSON() ([]byte, error) {
+	rows := make([][]string, 0, len(t.rows))
+	for _, row := range t.rows {
+		rows = append(rows, stripANSIRow(row))
+	}
+	if t.header == nil {
+		return json.Marshal(rows)
+	}
+
+	n := t.colCount()
+	keys := make([][]byte, n)
+	seen := make(map[string]bool, n)
+	for k := range n {
+		name := ""
+		if k < len(t.header) {
+			name = StripANSI(t.header[k])
+		}
+		// 键必须唯一，否则解析时会丢失列：空表头用列号，重复的表头加上 "_列号"
+		switch {
+		case name == "":
+			name = strconv.Itoa(k + 1)
+		case seen[name]:
+			name += "_" + strconv.Itoa(k+1)
+		}
+		for seen[name] {
+			name += "_"
+		}
+		seen[name] = true
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		keys[k] = key
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for k := range n {
+			if k > 0 {
+				buf.WriteByte(',')
+			}
+			cell := ""
+			if k < len(row) {
+				cell = row[k]
+			}
+			value, err := json.Marshal(cell)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(keys[k])
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func stripANSIRow(row []string) []string {
+	if row == nil {
+		return nil
+	}
+	cells := make([]string, len(row))
+	for k, cell := range row {
+		cells[k] = StripANSI(cell)
+	}
+	return cells
+}
@@ -0,0 +1,255 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 表格中时间的默认格式
+const tableTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// TableFromStructs 把结构体切片转换为表格，每个元素一行
+// T 可以是结构体或结构体指针，nil元素输出空行
+//
+// 通过 `table:"表头,格式"` 设置列，`table:"-"` 忽略字段，没有表头时使用字段路径（如 Addr.City）
+// 格式：
+//   - 浮点数：小数位数和后缀，如 "2"、".1%"，默认同 Float2String 保留2位小数
+//   - time.Time：时间的layout，如 "2006-01-02"，默认 "2006-01-02 15:04:05"，零值输出空
+//   - 含有 % 时作为 fmt.Sprintf 的格式，适用于所有类型
+//
+// 没有表头的嵌套结构体字段（time.Time 和实现 fmt.Stringer 的除外）会展开为多列，匿名嵌入的字段不加前缀
+// columns 选择列并指定顺序，可以是字段路径或表头，为空时输出所有导出字段
+func TableFromStructs[T any](items []T, columns ...string) (*Table, error) {
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("TableFromStructs requires a struct type, got %s", typ)
+	}
+
+	cols := structColumns(typ, "", nil, nil)
+	if len(columns) > 0 {
+		selected := make([]structColumn, 0, len(columns))
+		for _, name := range columns {
+			col, err := selectStructColumn(typ, cols, name)
+			if err != nil {
+				return nil, err
+			}
+			selected = append(selected, col)
+		}
+		cols = selected
+	}
+
+	header := make([]string, len(cols))
+	for k, col := range cols {
+		header[k] = col.header
+	}
+	t := NewTable().SetHeader(header...)
+	for _, item := range items {
+		v := reflect.ValueOf(&item).Elem()
+		row := make([]string, len(cols))
+		for k, col := range cols {
+			row[k] = formatStructField(structFieldByIndex(v, col.index), col.format)
+		}
+		t.AddRow(row...)
+	}
+	return t, nil
+}
+
+// structColumn 结构体字段对应的列
+type structColumn struct {
+	path   string // 字段路径，如 Addr.City
+	header string
+	format string
+	index  []int // reflect 的字段索引路径
+}
+
+// structColumns 按声明顺序列出结构体的列，展开嵌套的结构体
+// visiting 记录正在展开的类型，避免自引用的类型无限递归
+func structColumns(typ reflect.Type, prefix string, index []int, visiting []reflect.Type) []structColumn {
+	visiting = append(visiting, typ)
+	var cols []structColumn
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("table")
+		if tag == "-" {
+			continue
+		}
+		header, format, _ := strings.Cut(tag, ",")
+		path := prefix + field.Name
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		ft := derefType(field.Type)
+		if header == "" && expandStructField(ft) {
+			if containsType(visiting, ft) {
+				continue
+			}
+			nextPrefix := path + "."
+			if field.Anonymous {
+				nextPrefix = prefix
+			}
+			cols = append(cols, structColumns(ft, nextPrefix, fieldIndex, visiting)...)
+			continue
+		}
+		if !field.IsExported() { // 非结构体的匿名字段，未导出则无法读取
+			continue
+		}
+		if header == "" {
+			header = path
+		}
+		cols = append(cols, structColumn{path: path, header: header, format: format, index: fieldIndex})
+	}
+	return cols
+}
+
+// selectStructColumn 按字段路径或表头选择列，不在默认列中的字段路径（如带表头的嵌套结构体的字段）也可以选择
+func selectStructColumn(typ reflect.Type, cols []structColumn, name string) (structColumn, error) {
+	for _, col := range cols {
+		if col.path == name {
+			return col, nil
+		}
+	}
+	for _, col := range cols {
+		if col.header == name {
+			return col, nil
+		}
+	}
+
+	col := structColumn{path: name, header: name}
+	ft := typ
+	for _, part := range strings.Split(name, ".") {
+		ft = derefType(ft)
+		if ft.Kind() != reflect.Struct {
+			return col, fmt.Errorf("table column %q not found in %s", name, typ)
+		}
+		field, ok := ft.FieldByName(part)
+		if !ok || !field.IsExported() {
+			return col, fmt.Errorf("table column %q not found in %s", name, typ)
+		}
+		col.index = append(col.index, field.Index...)
+		ft = field.Type
+	}
+	// 表头和格式只取最后一个字段的标签
+	header, format, _ := strings.Cut(derefField(typ, col.index).Tag.Get("table"), ",")
+	if header != "" && header != "-" {
+		col.header = header
+	}
+	col.format = format
+	return col, nil
+}
+
+// structFieldByIndex 按索引路径取字段，路径上有nil指针时返回无效的值
+func structFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// formatStructField 把字段的值格式化为单元格
+func formatStructField(v reflect.Value, format string) string {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if strings.Contains(format, "%") && !isFloatKind(v.Kind()) {
+		return fmt.Sprintf(format, v.Interface())
+	}
+
+	switch {
+	case v.Type() == timeType:
+		tm := v.Interface().(time.Time)
+		if tm.IsZero() {
+			return ""
+		}
+		if format == "" {
+			format = tableTimeLayout
+		}
+		return tm.Format(format)
+	case v.Type() == durationType:
+		return v.Interface().(time.Duration).String()
+	case isFloatKind(v.Kind()):
+		return formatTableFloat(v.Float(), format)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// formatTableFloat 格式为 [.]小数位数[后缀]，如 "2"、".1%"；不是这种格式但含有 % 时作为 fmt.Sprintf 的格式
+func formatTableFloat(f float64, format string) string {
+	spec := strings.TrimPrefix(format, ".")
+	end := 0
+	for end < len(spec) && spec[end] >= '0' && spec[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		if strings.Contains(format, "%") && format != "%" {
+			return fmt.Sprintf(format, f)
+		}
+		return Float2String(f, format)
+	}
+	prec, _ := strconv.Atoi(spec[:end])
+	b := make([]byte, 0, 16+len(spec)-end)
+	b = strconv.AppendFloat(b, f, 'f', prec, 64)
+	b = append(b, spec[end:]...)
+	return string(b)
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// expandStructField 没有表头的结构体字段是否展开为多列
+func expandStructField(ft reflect.Type) bool {
+	return ft.Kind() == reflect.Struct && ft != timeType &&
+		!ft.Implements(stringerType) && !reflect.PointerTo(ft).Implements(stringerType)
+}
+
+// derefField 按索引路径取字段的定义，路径上的指针类型会被解引用
+func derefField(typ reflect.Type, index []int) reflect.StructField {
+	var field reflect.StructField
+	for _, i := range index {
+		field = derefType(typ).Field(i)
+		typ = field.Type
+	}
+	return field
+}
+
+func derefType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func containsType(types []reflect.Type, typ reflect.Type) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorDepth 终端支持的颜色数量
+type ColorDepth int
+
+const (
+	ColorDepthNone      ColorDepth = iota // 不支持颜色
+	ColorDepth16                          // 16色
+	ColorDepth256                         // 256色
+	ColorDepthTrueColor                   // 24位真彩色
+)
+
+// TermSize 终端的大小
+type TermSize struct {
+	Cols int // 列数
+	Rows int // 行数
+}
+
+// IsTerminal f 是否为终端
+func IsTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	return isTerminalFile(f)
+}
+
+// StdoutIsTerminal 标准输出是否为终端，输出被重定向到文件或管道时为false
+func StdoutIsTerminal() bool {
+	return IsTerminal(os.Stdout)
+}
+
+// StderrIsTerminal 标准错误是否为终端
+func StderrIsTerminal() bool {
+	return IsTerminal(os.Stderr)
+}
+
+// GetTerminalSize 获取终端的大小，f 不是终端时返回错误
+// 获取失败时可以用 COLUMNS、LINES 环境变量兜底，见 TerminalSizeOr
+func GetTerminalSize(f *os.File) (TermSize, error) {
+	return terminalSize(f)
+}
+
+// TerminalSizeOr 获取终端的大小，获取失败时依次使用 COLUMNS/LINES 环境变量和 def
+func TerminalSizeOr(f *os.File, def TermSize) TermSize {
+	if size, err := GetTerminalSize(f); err == nil && size.Cols > 0 && size.Rows > 0 {
+		return size
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		def.Cols = cols
+	}
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		def.Rows = rows
+	}
+	return def
+}
+
+// WatchTerminalResize 监听终端大小的变化（linux 下为 SIGWINCH），ctx 结束后关闭channel
+// 只发送最新的大小，来不及读取的中间变化会被丢弃。不支持的平台不会发送任何事件
+func WatchTerminalResize(ctx context.Context, f *os.File) <-chan TermSize {
+	ch := make(chan TermSize, 1)
+	go func() {
+		defer close(ch)
+		notify, stop := notifyTerminalResize()
+		defer stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notify:
+			}
+			size, err := GetTerminalSize(f)
+			if err != nil {
+				continue
+			}
+			select { // 丢弃没有读取的旧大小
+			case <-ch:
+			default:
+			}
+			ch <- size
+		}
+	}()
+	return ch
+}
+
+// DetectColorDepth 根据环境变量判断终端支持的颜色数量
+// NO_COLOR 不为空时不支持；COLORTERM 为 truecolor/24bit 时为真彩色；TERM 含 256color 时为256色；
+// TERM 为空或 dumb 时不支持；其他为16色
+func DetectColorDepth() ColorDepth {
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorDepthNone
+	}
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return ColorDepthTrueColor
+	}
+	term := strings.ToLower(os.Getenv("TERM"))
+	switch {
+	case term == "" || term == "dumb":
+		return ColorDepthNone
+	case strings.Contains(term, "truecolor") || strings.Contains(term, "direct"):
+		return ColorDepthTrueColor
+	case strings.Contains(term, "256color"):
+		return ColorDepth256
+	default:
+		return ColorDepth16
+	}
+}
+
+// isTerminalWriter w 是否为终端
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f)
+}
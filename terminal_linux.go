@@ -0,0 +1,55 @@
+//go:build linux
+
+package util
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// ioctl 通过 SyscallConn 获取fd，避免 f.Fd() 把文件切换为阻塞模式
+func ioctlFile(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminalFile(f *os.File) bool {
+	var termios syscall.Termios
+	return ioctlFile(f, syscall.TCGETS, unsafe.Pointer(&termios)) == nil
+}
+
+// ioctl TIOCGWINSZ 的结果
+type winsize struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+func terminalSize(f *os.File) (TermSize, error) {
+	var ws winsize
+	if err := ioctlFile(f, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return TermSize{}, os.NewSyscallError("ioctl TIOCGWINSZ", err)
+	}
+	return TermSize{Cols: int(ws.Col), Rows: int(ws.Row)}, nil
+}
+
+func notifyTerminalResize() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	return ch, func() { signal.Stop(ch) }
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+	"os"
+)
+
+// 没有ioctl时退化为判断是否为字符设备
+func isTerminalFile(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func terminalSize(f *os.File) (TermSize, error) {
+	return TermSize{}, errors.New("terminal size is not supported on this platform")
+}
+
+func notifyTerminalResize() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
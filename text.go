@@ -1,21 +1,22 @@
 package util
 
+// TextRed 红色文字，不是终端或设置了 NO_COLOR 时原样返回，见 StyleEnabled
 func TextRed(src string) string {
-	return "\033[31m" + src + "\033[0m"
+	return NewStyle().Fg(ColorRed).Render(src)
 }
 
 func TextGreen(src string) string {
-	return "\033[32m" + src + "\033[0m"
+	return NewStyle().Fg(ColorGreen).Render(src)
 }
 
 func TextYellow(src string) string {
-	return "\033[33m" + src + "\033[0m"
+	return NewStyle().Fg(ColorYellow).Render(src)
 }
 
 func TextBlue(src string) string {
-	return "\033[34m" + src + "\033[0m"
+	return NewStyle().Fg(ColorBlue).Render(src)
 }
 
 func TextPurple(src string) string {
-	return "\033[35m" + src + "\033[0m"
+	return NewStyle().Fg(ColorPurple).Render(src)
 }
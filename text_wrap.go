@@ -0,0 +1,147 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// 避头：不能出现在行首的标点，折行时和前一个字符放在同一行
+const kinsokuNoStart = "，。、；：？！）》」』】〕〉｝〗〙”’…‥・ー～％,.;:?!)]}%" +
+	"ぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮヵヶ々"
+
+// 避尾：不能出现在行尾的标点，折行时和后一个字符放在同一行
+const kinsokuNoEnd = "（《「『【〔〈｛〖〘“‘([{$￥£"
+
+// wrapUnit 折行时不能拆开的一段：一个英文单词、一个中文字符（连同粘连的标点）或一个空白
+type wrapUnit struct {
+	cells []ansiCell
+	width int
+	space bool
+	latin bool // 最后一个字符是窄字符，后面的窄字符属于同一个单词
+}
+
+// StrWordWrap 按 width 列折行，中文等宽字符之间可以断开，英文在单词之间断开，原有的换行符保留
+// 遵守避头尾规则：句号、逗号、右括号等不出现在行首，左括号、左引号等不出现在行尾
+// 折行处的空白被去掉，输入开头和换行符之后的缩进保留；超过 width 的单词才会被硬拆开
+// 与 StrWrap 相同，每行结尾关闭样式，下一行开头重新打开
+func StrWordWrap(s string, width int) []string {
+	if width <= 0 {
+		return strings.Split(s, "\n")
+	}
+	cells, suffix := parseANSICells(s)
+
+	lines := make([]string, 0, 4)
+	var b strings.Builder
+	used := 0
+	var last ansiCell
+	var pending []ansiCell // 单词之间的空白，换行时丢弃
+	pendingWidth := 0
+	wrapped := false // 当前行是折行产生的（而不是输入的开头或换行符之后）
+	newLine := func() {
+		b.WriteString(last.closing())
+		lines = append(lines, b.String())
+		b.Reset()
+		used = 0
+		last = ansiCell{}
+		pending, pendingWidth = pending[:0], 0
+	}
+	write := func(cell ansiCell) {
+		if used == 0 && last.text == "" {
+			b.WriteString(cell.opening()) // 行首重新打开样式，已包含 prefix 中的样式
+		} else {
+			b.WriteString(cell.prefix)
+		}
+		b.WriteString(cell.text)
+		used += cell.width
+		last = cell
+	}
+
+	start := 0
+	for i := 0; i <= len(cells); i++ {
+		if i < len(cells) && cells[i].text != "\n" && cells[i].text != "\r\n" {
+			continue
+		}
+		for _, unit := range splitWrapUnits(cells[start:i]) {
+			switch {
+			case unit.space:
+				if used > 0 {
+					pending = append(pending, unit.cells...)
+					pendingWidth += unit.width
+				} else if !wrapped {
+					// 输入开头或换行符之后的缩进保留，只去掉折行处的空白
+					for _, cell := range unit.cells {
+						if used+cell.width > width {
+							break
+						}
+						write(cell)
+					}
+				}
+				continue
+			case used > 0 && used+pendingWidth+unit.width > width:
+				newLine()
+				wrapped = true
+			default:
+				for _, cell := range pending {
+					write(cell)
+				}
+				pending, pendingWidth = pending[:0], 0
+			}
+			for _, cell := range unit.cells {
+				// 比整行还宽的单词只能硬拆开
+				if used > 0 && used+cell.width > width {
+					newLine()
+					wrapped = true
+				}
+				write(cell)
+			}
+		}
+		if i < len(cells) {
+			newLine()
+			wrapped = false
+		}
+		start = i + 1
+	}
+	b.WriteString(suffix)
+	lines = append(lines, b.String())
+	return lines
+}
+
+// splitWrapUnits 把一行的字符分为不能拆开的段
+func splitWrapUnits(cells []ansiCell) []wrapUnit {
+	units := make([]wrapUnit, 0, len(cells))
+	glueNext := false // 上一个字符避尾，与这个字符粘连
+	for _, cell := range cells {
+		r, _ := utf8.DecodeRuneInString(cell.text)
+		space := cell.text == " " || cell.text == "\t" || r == '　'
+		narrow := cell.width < 2
+
+		if n := len(units); n > 0 && !space && !units[n-1].space &&
+			(glueNext || cell.width == 0 || strings.ContainsRune(kinsokuNoStart, r) || narrow && units[n-1].latin) {
+			units[n-1].cells = append(units[n-1].cells, cell)
+			units[n-1].width += cell.width
+			units[n-1].latin = narrow
+		} else {
+			units = append(units, wrapUnit{cells: []ansiCell{cell}, width: cell.width, space: space, latin: narrow && !space})
+		}
+		glueNext = !space && strings.ContainsRune(kinsokuNoEnd, r)
+	}
+	return units
+}
+
+// StrCenter 在两边补空格使字符串居中到 width 列，不能平分时右边多一个空格，已超过时原样返回
+func StrCenter(s string, width int) string {
+	return StrAlign(s, width, AlignCenter)
+}
+
+// StrAlign 按对齐方式补空格到 width 列，AlignAuto 同 AlignLeft，已超过时原样返回
+func StrAlign(s string, width int, align Align) string {
+	switch align {
+	case AlignRight:
+		return StrPadLeft(s, width)
+	case AlignCenter:
+		n := StrTerminalLen(s)
+		return StrPadRight(StrPadLeft(s, n+(width-n)/2), width)
+	default:
+		return StrPadRight(s, width)
+	}
+}
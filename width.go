@@ -0,0 +1,148 @@
+package util
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// 东亚宽度为 Ambiguous 的字符（如 ①、±、é、希腊字母）在CJK环境的终端中通常显示为2列，其他环境为1列
+var ambiguousWide atomic.Bool
+
+// SetAmbiguousWidth 设置 Ambiguous 字符是否按2列计算，默认按1列计算
+// 一般用法：SetAmbiguousWidth(AmbiguousWideFromEnv())
+func SetAmbiguousWidth(wide bool) {
+	ambiguousWide.Store(wide)
+}
+
+// AmbiguousWideFromEnv 根据 LC_ALL、LC_CTYPE、LANG 判断是否为中日韩的locale
+func AmbiguousWideFromEnv() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := os.Getenv(key)
+		if locale == "" {
+			continue
+		}
+		return HasPrefixs(strings.ToLower(locale), []string{"zh", "ja", "ko"})
+	}
+	return false
+}
+
+// RuneWidth 单个字符在终端中占的列数
+// 控制字符、组合符号、零宽字符为0，东亚宽字符和emoji为2，其他为1
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x7f: // ASCII快速路径
+		return 1
+	case r < 0xa0: // C1 控制字符
+		return 0
+	case isZeroWidthRune(r):
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	case width.EastAsianAmbiguous:
+		if ambiguousWide.Load() {
+			return 2
+		}
+	}
+	return 1
+}
+
+// 不单独占位的字符：组合符号、格式字符（零宽空格、零宽连接符等）、变体选择符、韩文字母的中声和终声
+func isZeroWidthRune(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf) ||
+		isVariationSelector(r) ||
+		(r >= 0x1160 && r <= 0x11ff)
+}
+
+func isVariationSelector(r rune) bool {
+	return (r >= 0xfe00 && r <= 0xfe0f) || (r >= 0xe0100 && r <= 0xe01ef)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isEmojiModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+const (
+	zeroWidthJoiner      = 0x200d
+	textPresentation     = 0xfe0e // VS15，按文本样式显示
+	emojiPresentation    = 0xfe0f // VS16，按emoji样式显示
+	graphemeMaxRuneWidth = 2
+)
+
+// nextGrapheme 获取字符串开头的字素簇（用户看到的一个字符）
+// 返回值:
+//
+//	int - 字素簇的字节长度
+//	int - 字素簇在终端中占的列数
+//
+// 注意:
+//
+//	这是 UAX #29 的简化实现，覆盖组合符号、变体选择符、零宽连接的emoji序列、肤色修饰符、国旗、CRLF
+func nextGrapheme(s string) (int, int) {
+	if s == "" {
+		return 0, 0
+	}
+	base, size := utf8.DecodeRuneInString(s)
+	if base < utf8.RuneSelf && (len(s) == 1 || s[1] < utf8.RuneSelf) { // ASCII快速路径
+		if base == '\r' && len(s) > 1 && s[1] == '\n' {
+			return 2, 0
+		}
+		return 1, RuneWidth(base)
+	}
+
+	w := RuneWidth(base)
+	// 国旗由两个区域指示符组成
+	if isRegionalIndicator(base) {
+		if next, nextSize := utf8.DecodeRuneInString(s[size:]); isRegionalIndicator(next) {
+			return size + nextSize, graphemeMaxRuneWidth
+		}
+		return size, w
+	}
+
+	for size < len(s) {
+		r, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case r == zeroWidthJoiner:
+			size += n
+			if size < len(s) { // 被连接的字符属于同一个字素簇
+				_, joined := utf8.DecodeRuneInString(s[size:])
+				size += joined
+			}
+		case r == emojiPresentation:
+			size += n
+			w = graphemeMaxRuneWidth
+		case r == textPresentation:
+			size += n
+			w = min(w, 1)
+		case isZeroWidthRune(r) || isEmojiModifier(r):
+			size += n
+		default:
+			return size, w
+		}
+	}
+	return size, w
+}
+
+// graphemes 把字符串切分为字素簇
+func graphemes(s string) []string {
+	clusters := make([]string, 0, len(s))
+	for s != "" {
+		n, _ := nextGrapheme(s)
+		clusters = append(clusters, s[:n])
+		s = s[n:]
+	}
+	return clusters
+}
@@ -0,0 +1,196 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrPoolClosed 工作池已关闭，不再接受任务
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// PanicError 任务中的panic转换成的错误
+type PanicError struct {
+	Value any    // recover() 的值
+	Stack []byte // panic时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// callRecover 执行 fn，把panic转换为 PanicError
+func callRecover(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// PoolOptions 工作池的选项
+type PoolOptions struct {
+	Workers   int             // 工作协程数，<=0 时为1
+	QueueSize int             // 等待执行的任务队列长度，队列满时 Submit 阻塞
+	OnError   func(err error) // 任务出错时调用（在工作协程中），为nil时错误由 Close 汇总返回
+}
+
+// WorkerPool 有界的工作池：固定数量的工作协程从队列中取任务执行
+// 任务的panic会被恢复并转换为 PanicError，不影响其他任务
+// 例子：在 LoopExecute 中每轮 Submit 新发现的链接，程序退出时 Shutdown 等待正在执行的任务
+type WorkerPool struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	jobs    chan func(ctx context.Context) error
+	onError func(err error)
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex // 保护 closed，只在检查和修改时短暂持有，不在阻塞的发送期间持有
+	closed  bool
+	done    chan struct{}  // 关闭时close，唤醒阻塞的 Submit
+	submits sync.WaitGroup // 正在进行的 Submit，全部返回后才能关闭 jobs
+
+	errMu sync.Mutex
+	errs  []error
+}
+
+// NewWorkerPool 创建并启动工作池，ctx 取消时正在执行的任务通过任务的 ctx 得知，队列中的任务不再执行
+func NewWorkerPool(ctx context.Context, opts PoolOptions) *WorkerPool {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &WorkerPool{
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    make(chan func(ctx context.Context) error, max(opts.QueueSize, 0)),
+		onError: opts.OnError,
+		done:    make(chan struct{}),
+	}
+	for range max(opts.Workers, 1) {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit 提交任务，队列满时阻塞，直到有空位、ctx 取消或工作池关闭
+// 任务中也可以调用 Submit，工作池关闭时返回 ErrPoolClosed，不会阻塞关闭
+func (p *WorkerPool) Submit(ctx context.Context, job func(ctx context.Context) error) error {
+	if !p.beginSubmit() {
+		return ErrPoolClosed
+	}
+	defer p.submits.Done()
+
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrPoolClosed
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// TrySubmit 提交任务，队列满或工作池已关闭时立即返回false
+func (p *WorkerPool) TrySubmit(job func(ctx context.Context) error) bool {
+	if !p.beginSubmit() {
+		return false
+	}
+	defer p.submits.Done()
+
+	if p.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// beginSubmit 工作池未关闭时登记一次提交，返回false表示已关闭
+func (p *WorkerPool) beginSubmit() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.submits.Add(1)
+	return true
+}
+
+// Close 不再接受新任务，等待队列中和正在执行的任务全部完成
+// 返回值：没有设置 OnError 时，所有任务的错误用 errors.Join 合并返回
+func (p *WorkerPool) Close() error {
+	p.closeQueue()
+	p.wg.Wait()
+	p.cancel()
+	return p.joinErrors()
+}
+
+// Shutdown 同 Close，但最多等到 ctx 取消：超时后取消所有任务的 ctx，丢弃队列中的任务，等正在执行的任务返回后返回 ctx.Err()
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.closeQueue()
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return p.joinErrors()
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// closeQueue 不再接受新任务：唤醒阻塞的 Submit，等它们返回后关闭 jobs，工作协程执行完队列中的任务后退出
+func (p *WorkerPool) closeQueue() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+
+	p.submits.Wait()
+	close(p.jobs)
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		if p.ctx.Err() != nil {
+			continue // 已取消，丢弃剩下的任务
+		}
+		if err := callRecover(func() error { return job(p.ctx) }); err != nil {
+			p.report(err)
+		}
+	}
+}
+
+func (p *WorkerPool) report(err error) {
+	if p.onError != nil {
+		p.onError(err)
+		return
+	}
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	p.errs = append(p.errs, err)
+}
+
+func (p *WorkerPool) joinErrors() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return errors.Join(p.errs...)
+}